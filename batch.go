@@ -0,0 +1,212 @@
+package servicemesh
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Batch is a handle for a group of services which were added to the mesh
+// together with AddAll. It reports the aggregate completion of the startup
+// as well as any errors encountered for individual services.
+//
+// The embedded Operation completes once every service in the batch has either
+// been initialized or has failed, and its error is the joined errors of all
+// services of the batch.
+type Batch struct {
+	*Operation
+
+	mu   sync.Mutex
+	errs map[Service]error
+}
+
+// Errors returns the errors encountered for each service in the batch.
+// Services which started successfully are not present in the map.
+func (b *Batch) Errors() map[Service]error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	errs := make(map[Service]error, len(b.errs))
+	for service, err := range b.errs {
+		errs[service] = err
+	}
+
+	return errs
+}
+
+func (b *Batch) joinedErrors() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	list := make([]error, 0, len(b.errs))
+	for _, err := range b.errs {
+		list = append(list, err)
+	}
+
+	return errors.Join(list...)
+}
+
+func (b *Batch) fail(service Service, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.errs == nil {
+		b.errs = make(map[Service]error)
+	}
+
+	b.errs[service] = err
+}
+
+// AddAll adds several services to the mesh with a single coordinated startup.
+//
+// Every service is registered before any dependencies are resolved, so no
+// service can miss a sibling from the same batch. The services are then
+// initialized in tiers: every service whose dependencies can be resolved from
+// initialized services is initialized in parallel, after which the remaining
+// services try again. While services of the mesh which are not part of the
+// batch are still being added, the remaining services wait for them. A
+// service whose dependencies are still unresolved once no further progress
+// can be made is reported with ErrUnresolvedDependencies, and is left to keep
+// resolving in the background just like a service added with Add.
+func (m *mesh) AddAll(services ...Service) *Batch {
+	m.Init(nil) // always ensure service mesh is init
+
+	for _, service := range services {
+		m.register(service)
+	}
+
+	b := &Batch{Operation: newOperation()}
+
+	go func() {
+		m.initTiers(b, services)
+		b.complete(b.joinedErrors())
+	}()
+
+	return b
+}
+
+// initTiers initializes the services of a batch in dependency order.
+func (m *mesh) initTiers(b *Batch, pending []Service) {
+	for tier := 0; len(pending) > 0; tier++ {
+		var ready, blocked []Service
+
+		for _, service := range pending {
+			resolver, ok := service.(HasDependencies)
+			if !ok {
+				ready = append(ready, service)
+				continue
+			}
+
+			if tier == 0 {
+				m.beginResolution(resolver)
+			}
+
+			// only the services of earlier tiers are candidates, so that a
+			// dependency is initialized before its dependents, along with
+			// lazy services, which are initialized when they are needed
+			candidates := append(m.initializedServices(), m.pendingLazyServices()...)
+
+			if !resolver.DependenciesResolved() && !m.offerDependencies(resolver, candidates) {
+				blocked = append(blocked, service)
+				continue
+			}
+
+			m.emit(EventDependencyResolutionEnded, resolver)
+			ready = append(ready, service)
+		}
+
+		if len(ready) == 0 && m.inFlight(blocked) {
+			// a dependency may still be on its way, so give it time
+			time.Sleep(dependencyResolutionDwellDuration)
+
+			pending = blocked
+
+			continue
+		}
+
+		if len(ready) == 0 {
+			for _, service := range blocked {
+				m.logger.Warn("unresolved dependencies", "service", service.Name())
+				b.fail(service, fmt.Errorf("%w: %s", ErrUnresolvedDependencies, service.Name()))
+
+				go m.retryInBackground(service.(HasDependencies))
+			}
+
+			return
+		}
+
+		m.logger.Debug("initializing batch tier", "tier", tier, "services", len(ready))
+
+		var wg sync.WaitGroup
+
+		for _, service := range ready {
+			wg.Add(1)
+
+			go func(service Service) {
+				defer wg.Done()
+
+				if err := m.safeInitService(service); err != nil {
+					b.fail(service, err)
+				}
+			}(service)
+		}
+
+		wg.Wait()
+
+		pending = blocked
+	}
+}
+
+// safeInitService initializes a service, recovering from a panic within the
+// Init method of the service and reporting it as an error.
+func (m *mesh) safeInitService(service Service) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Error("service init panicked", "service", service.Name(), "panic", r)
+			m.setPhase(service, phaseFailed)
+			err = fmt.Errorf("%w: %s: %v", ErrInitPanicked, service.Name(), r)
+		}
+	}()
+
+	m.initService(service)
+
+	return nil
+}
+
+// retryInBackground keeps resolving the dependencies of a batch service which
+// was reported as unresolved, and initializes it once they are resolved. Its
+// resolution has already begun with the batch, so it is not announced again.
+func (m *mesh) retryInBackground(resolver HasDependencies) {
+	m.finishResolution(resolver)
+
+	if err := m.safeInitService(resolver); err != nil {
+		m.logger.Error("service init failed", "service", resolver.Name(), "error", err)
+	}
+}
+
+// inFlight reports whether a registered service, other than the given ones,
+// is on its way to being initialized: it is about to be initialized or being
+// initialized, or its dependencies have been resolved. Services which still
+// wait for dependencies of their own, and lazy services, are not counted.
+func (m *mesh) inFlight(exclude []Service) bool {
+	for owner := m; owner != nil; owner = owner.parent {
+		for _, service := range owner.Services() {
+			if slices.Contains(exclude, service) || owner.isReady(service) || owner.pendingLazy(service) != nil {
+				continue
+			}
+
+			switch owner.phaseOf(service) {
+			case phaseAdded, phaseInitializing:
+				return true
+			case phaseResolving:
+				if resolver, ok := service.(HasDependencies); ok && resolver.DependenciesResolved() {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
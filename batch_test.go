@@ -0,0 +1,153 @@
+package servicemesh
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAddAll(t *testing.T) {
+	m := New()
+
+	provider := &providerService{}
+	consumer := &dependentService{}
+	orphan := &orphanService{}
+
+	// the consumer is listed before its provider
+	b := m.AddAll(consumer, provider, orphan)
+	b.Wait(context.Background())
+
+	if consumer.dependency.Load() != provider {
+		t.Error("expected dependency to be resolved from the same batch")
+	}
+
+	errs := b.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("expected exactly one error, got %d", len(errs))
+	}
+
+	if !errors.Is(errs[orphan], ErrUnresolvedDependencies) {
+		t.Errorf("unexpected error for orphan service: %v", errs[orphan])
+	}
+
+	if !errors.Is(b.Err(), ErrUnresolvedDependencies) {
+		t.Errorf("unexpected aggregate error: %v", b.Err())
+	}
+}
+
+type orphanService struct{}
+
+func (o *orphanService) Init(_ Mesh) {}
+
+func (o *orphanService) Name() string { return "orphan" }
+
+func (o *orphanService) DependenciesResolved() bool { return false }
+
+func (o *orphanService) ResolveDependencies(_ []Service) {}
+
+type slowProvider struct {
+	initialized atomic.Bool
+}
+
+func (p *slowProvider) Init(_ Mesh) {
+	time.Sleep(50 * time.Millisecond)
+	p.initialized.Store(true)
+}
+
+func (p *slowProvider) Name() string { return "slow provider" }
+
+type slowDependent struct {
+	dependency         atomic.Pointer[slowProvider]
+	dependencyWasReady atomic.Bool
+}
+
+func (d *slowDependent) Init(_ Mesh) {
+	d.dependencyWasReady.Store(d.dependency.Load().initialized.Load())
+}
+
+func (d *slowDependent) Name() string { return "slow dependent" }
+
+func (d *slowDependent) DependenciesResolved() bool { return d.dependency.Load() != nil }
+
+func (d *slowDependent) ResolveDependencies(services []Service) {
+	for _, service := range services {
+		if candidate, ok := service.(*slowProvider); ok {
+			d.dependency.Store(candidate)
+		}
+	}
+}
+
+func TestAddAllInitializesDependenciesFirst(t *testing.T) {
+	m := New()
+
+	provider := &slowProvider{}
+	dependent := &slowDependent{}
+
+	if err := m.AddAll(dependent, provider).Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !dependent.dependencyWasReady.Load() {
+		t.Errorf("expected the dependency to be initialized before its dependent")
+	}
+}
+
+func TestAddAllWaitsForServicesInFlight(t *testing.T) {
+	m := New()
+
+	provider := &slowProvider{}
+	dependent := &slowDependent{}
+
+	// the provider is still being initialized when the batch starts
+	m.Add(provider)
+
+	if err := m.AddAll(dependent).Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !dependent.dependencyWasReady.Load() {
+		t.Errorf("expected the dependency to be initialized before its dependent")
+	}
+}
+
+// panickyDependent depends on a *providerService, and panics once it is
+// initialized.
+type panickyDependent struct {
+	dependentService
+}
+
+func (d *panickyDependent) Init(_ Mesh) { panic("init boom") }
+
+func (d *panickyDependent) Name() string { return "panicky dependent" }
+
+func TestAddAllRetriesSafely(t *testing.T) {
+	m := New()
+
+	var started atomic.Int32
+
+	Subscribe(m, func(e DependencyResolutionStarted) {
+		if e.Service.Name() == "panicky dependent" {
+			started.Add(1)
+		}
+	})
+
+	dependent := &panickyDependent{}
+
+	if err := m.AddAll(dependent).Wait(context.Background()); !errors.Is(err, ErrUnresolvedDependencies) {
+		t.Fatalf("expected the dependency to be missing, got %v", err)
+	}
+
+	// the service keeps resolving in the background, and its panic is
+	// recovered once it is initialized
+	_ = m.Add(&providerService{}).Wait(context.Background())
+
+	waitFor(t, "the background resolution to finish", func() bool {
+		return m.(*mesh).phaseOf(dependent) == phaseFailed
+	})
+
+	if n := started.Load(); n != 1 {
+		t.Errorf("expected the resolution to be announced once, got %d", n)
+	}
+}
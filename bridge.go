@@ -0,0 +1,287 @@
+package servicemesh
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+// bridgeSeenRetention is the number of recently received events a bridge
+// remembers, to recognize events arriving over several paths.
+const bridgeSeenRetention = 4096
+
+// EventBridge is a service which connects the event bus of its mesh to the
+// event buses of meshes in other processes, over unix domain sockets or TCP.
+// A bridge can listen for connections, connect to other bridges, or both.
+//
+// Events emitted on the mesh which match one of the forwarded patterns are
+// sent to every connected bridge, which emits them on its own mesh with their
+// source, correlation ID, and origin preserved. A bridge relays the events it
+// receives to its other connections, so that several processes can share a
+// single listening bridge as a hub.
+//
+// Events are never sent back to the mesh they originate from, and an event
+// arriving over several paths is only emitted once, so bridges may be
+// connected in any topology.
+//
+// Arguments are sent in a portable form: services are sent by name and ID,
+// and errors by their message. Other arguments must be supported by the
+// codec of the bridge, see JSONCodec and GobCodec.
+type EventBridge struct {
+	mu          sync.Mutex
+	links       netLinks
+	mesh        *mesh
+	logger      *slog.Logger
+	codec       Codec
+	patterns    []string
+	peers       map[*bridgePeer]bool
+	origins     map[string]bool
+	seen        map[bridgeKey]bool
+	seenOrder   []bridgeKey
+	unsubscribe []Unsubscribe
+}
+
+// bridgeKey identifies an event across processes.
+type bridgeKey struct {
+	origin   string
+	sequence uint64
+}
+
+// bridgeMessage is a single event sent between bridges.
+type bridgeMessage struct {
+	Name     string
+	Args     []any
+	Envelope Envelope
+}
+
+// bridgePeer is a connection to another bridge.
+type bridgePeer struct {
+	mu      sync.Mutex
+	encoder Encoder
+}
+
+func (p *bridgePeer) send(msg bridgeMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.encoder.Encode(msg)
+}
+
+// NewEventBridge creates an event bridge service, which exchanges events
+// using the given codec. Configure where the bridge listens and connects to
+// with Listen and Connect, and which events it forwards with Forward, then
+// add it to a mesh.
+func NewEventBridge(codec Codec) *EventBridge {
+	return &EventBridge{
+		links:   newNetLinks(),
+		codec:   codec,
+		peers:   make(map[*bridgePeer]bool),
+		origins: make(map[string]bool),
+		seen:    make(map[bridgeKey]bool),
+	}
+}
+
+// Listen makes the bridge accept connections from other bridges on the given
+// network ("unix" or "tcp") and address. It must be called before the bridge
+// is added to a mesh.
+func (b *EventBridge) Listen(network, address string) {
+	b.links.listen = append(b.links.listen, netAddress{network, address})
+}
+
+// Connect makes the bridge connect to the bridge listening on the given
+// network and address. When the connection cannot be made, or is lost, the
+// bridge reconnects with a backoff. It must be called before the bridge is
+// added to a mesh.
+func (b *EventBridge) Connect(network, address string) {
+	b.links.dial = append(b.links.dial, netAddress{network, address})
+}
+
+// Forward sets the patterns of the events which the bridge sends to other
+// bridges, see SubscribePattern. By default, no events are forwarded. It must
+// be called before the bridge is added to a mesh.
+func (b *EventBridge) Forward(patterns ...string) {
+	b.patterns = append(b.patterns, patterns...)
+}
+
+// SetReconnect sets the delay before the first attempt to reconnect to a
+// bridge, and the maximum delay it doubles up to with every failed attempt.
+func (b *EventBridge) SetReconnect(initial, limit time.Duration) {
+	b.links.reconnectMin = initial
+	b.links.reconnectMax = limit
+}
+
+// Init starts listening, connecting, and forwarding events.
+func (b *EventBridge) Init(m Mesh) {
+	impl, ok := m.(*mesh)
+	if !ok {
+		b.logger.Error("starting event bridge", "error", ErrUnsupportedMesh)
+		return
+	}
+
+	b.mesh = impl
+	b.links.start(b.logger, b.serve)
+
+	for _, pattern := range b.patterns {
+		b.unsubscribe = append(b.unsubscribe, SubscribePattern(m, pattern, b.forward, WithName(b.Name())))
+	}
+}
+
+// Name returns the name of the service.
+func (b *EventBridge) Name() string {
+	return "Event Bridge"
+}
+
+// SetLogger sets the logger of the service.
+func (b *EventBridge) SetLogger(l *slog.Logger) {
+	b.logger = l
+}
+
+// Logger yields the logger of the service.
+func (b *EventBridge) Logger() *slog.Logger {
+	return b.logger
+}
+
+// Peers returns the number of bridges which are currently connected.
+func (b *EventBridge) Peers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.peers)
+}
+
+// OnShutdown stops forwarding events, and closes every connection.
+func (b *EventBridge) OnShutdown() {
+	for _, unsubscribe := range b.unsubscribe {
+		unsubscribe()
+	}
+
+	b.links.stop()
+}
+
+// serve receives events from a connected bridge, until the connection is
+// closed.
+func (b *EventBridge) serve(conn net.Conn) {
+	peer := &bridgePeer{encoder: b.codec.NewEncoder(conn)}
+
+	b.mu.Lock()
+	b.peers[peer] = true
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.peers, peer)
+		b.mu.Unlock()
+	}()
+
+	decoder := b.codec.NewDecoder(conn)
+
+	for {
+		var msg bridgeMessage
+
+		if err := decoder.Decode(&msg); err != nil {
+			if !b.links.stopping() && !errors.Is(err, net.ErrClosed) {
+				b.logger.Debug("bridge connection closed", "error", err)
+			}
+
+			return
+		}
+
+		b.receive(peer, msg)
+	}
+}
+
+// receive emits an event received from a bridge on the mesh, and relays it
+// to the other connected bridges.
+func (b *EventBridge) receive(from *bridgePeer, msg bridgeMessage) {
+	key := bridgeKey{msg.Envelope.Origin, msg.Envelope.Sequence}
+
+	b.mu.Lock()
+
+	// drop events which came back around to their origin, or which were
+	// already received over another path
+	if msg.Envelope.Origin == b.mesh.ServiceID(b.mesh) || b.seen[key] {
+		b.mu.Unlock()
+		return
+	}
+
+	b.remember(key)
+	b.origins[msg.Envelope.Origin] = true
+
+	var others []*bridgePeer
+
+	for peer := range b.peers {
+		if peer != from {
+			others = append(others, peer)
+		}
+	}
+
+	b.mu.Unlock()
+
+	for _, peer := range others {
+		if err := peer.send(msg); err != nil {
+			b.logger.Debug("relaying event", "event", msg.Name, "error", err)
+		}
+	}
+
+	args := make([]any, 0, len(msg.Args)+1)
+
+	for _, arg := range msg.Args {
+		args = append(args, restoreTypedArg(msg.Name, arg))
+	}
+
+	meta := Envelope{
+		Time:          msg.Envelope.Time,
+		Source:        msg.Envelope.Source,
+		SourceID:      msg.Envelope.SourceID,
+		Mesh:          msg.Envelope.Mesh,
+		CorrelationID: msg.Envelope.CorrelationID,
+		Origin:        msg.Envelope.Origin,
+	}
+
+	b.mesh.Emit(msg.Name, append(args, meta)...)
+}
+
+// remember records the key of a received event, forgetting the oldest key
+// once too many are remembered. The bridge must be locked by the caller.
+func (b *EventBridge) remember(key bridgeKey) {
+	if len(b.seenOrder) >= bridgeSeenRetention {
+		delete(b.seen, b.seenOrder[0])
+		b.seenOrder = b.seenOrder[1:]
+	}
+
+	b.seen[key] = true
+	b.seenOrder = append(b.seenOrder, key)
+}
+
+// forward sends an event emitted on the mesh to every connected bridge.
+// Events received from other bridges are relayed as they arrive, and are not
+// forwarded again.
+func (b *EventBridge) forward(e Event) {
+	b.mu.Lock()
+
+	if b.origins[e.Envelope.Origin] {
+		b.mu.Unlock()
+		return
+	}
+
+	peers := make([]*bridgePeer, 0, len(b.peers))
+	for peer := range b.peers {
+		peers = append(peers, peer)
+	}
+
+	b.mu.Unlock()
+
+	msg := bridgeMessage{Name: e.Name, Envelope: e.Envelope}
+
+	for _, arg := range e.Args {
+		msg.Args = append(msg.Args, b.mesh.portableArg(arg))
+	}
+
+	for _, peer := range peers {
+		if err := peer.send(msg); err != nil {
+			b.logger.Warn("forwarding event", "event", e.Name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package servicemesh
+
+import (
+	"context"
+	"encoding/gob"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEventBridge(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "bridge.sock")
+
+	// the connecting side starts first, and keeps retrying until the
+	// listening side is up
+	remote := New("remote")
+	remoteBridge := NewEventBridge(JSONCodec)
+	remoteBridge.Connect("unix", socket)
+	remoteBridge.Forward("ping")
+	remoteBridge.SetReconnect(5*time.Millisecond, 20*time.Millisecond)
+	_ = remote.Add(remoteBridge).Wait(context.Background())
+
+	local := New("local")
+	localBridge := NewEventBridge(JSONCodec)
+	localBridge.Listen("unix", socket)
+	localBridge.Forward("type *", "ping")
+	_ = local.Add(localBridge).Wait(context.Background())
+
+	defer localBridge.OnShutdown()
+	defer remoteBridge.OnShutdown()
+
+	waitFor(t, "the bridges to connect", func() bool {
+		return localBridge.Peers() == 1 && remoteBridge.Peers() == 1
+	})
+
+	orders := make(chan orderPlaced, 1)
+	envelopes := make(chan Envelope, 1)
+
+	SubscribeEnvelope(remote, func(e orderPlaced, env Envelope) {
+		orders <- e
+		envelopes <- env
+	})
+
+	_ = Publish(local, orderPlaced{ID: 7}, Envelope{Source: "shop", CorrelationID: "abc"}).Wait(context.Background())
+
+	if got := <-orders; got.ID != 7 {
+		t.Errorf("expected the typed event to cross the bridge, got %+v", got)
+	}
+
+	env := <-envelopes
+	if env.Source != "shop" || env.CorrelationID != "abc" || env.Origin != local.ServiceID(local.(*mesh)) {
+		t.Errorf("expected the envelope to be preserved, got %+v", env)
+	}
+
+	// both bridges forward "ping", but an event is never sent back to where
+	// it came from
+	var localPings, remotePings atomic.Int32
+
+	SubscribePattern(local, "ping", func(e Event) { localPings.Add(1) })
+	SubscribePattern(remote, "ping", func(e Event) { remotePings.Add(1) })
+
+	remote.Emit("ping")
+
+	waitFor(t, "the ping to cross the bridge", func() bool {
+		return localPings.Load() == 1
+	})
+
+	time.Sleep(50 * time.Millisecond)
+
+	if got := remotePings.Load(); got != 1 {
+		t.Errorf("expected the event not to loop back, got %d pings", got)
+	}
+}
+
+func TestEventBridgeHub(t *testing.T) {
+	gob.Register(orderPlaced{})
+
+	socket := filepath.Join(t.TempDir(), "hub.sock")
+
+	hub := New("hub")
+	hubBridge := NewEventBridge(GobCodec)
+	hubBridge.Listen("unix", socket)
+	hubBridge.Forward("type *")
+	_ = hub.Add(hubBridge).Wait(context.Background())
+
+	defer hubBridge.OnShutdown()
+
+	var spokes []Mesh
+	var received []*atomic.Int32
+
+	for _, name := range []string{"a", "b", "c"} {
+		spoke := New(name)
+		bridge := NewEventBridge(GobCodec)
+		bridge.Connect("unix", socket)
+		bridge.Forward("type *")
+		_ = spoke.Add(bridge).Wait(context.Background())
+
+		defer bridge.OnShutdown()
+
+		count := &atomic.Int32{}
+		Subscribe(spoke, func(e orderPlaced) { count.Add(1) })
+
+		spokes = append(spokes, spoke)
+		received = append(received, count)
+	}
+
+	waitFor(t, "the spokes to connect", func() bool {
+		return hubBridge.Peers() == len(spokes)
+	})
+
+	var atHub atomic.Int32
+	Subscribe(hub, func(e orderPlaced) { atHub.Add(1) })
+
+	_ = Publish(spokes[0], orderPlaced{ID: 1}).Wait(context.Background())
+
+	waitFor(t, "the event to be relayed to every spoke", func() bool {
+		return received[1].Load() == 1 && received[2].Load() == 1 && atHub.Load() == 1
+	})
+
+	time.Sleep(50 * time.Millisecond)
+
+	for i, count := range received {
+		if got := count.Load(); got != 1 {
+			t.Errorf("expected spoke %d to receive the event once, got %d", i, got)
+		}
+	}
+}
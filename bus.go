@@ -0,0 +1,612 @@
+package servicemesh
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Unsubscribe removes a subscription from the event bus of the mesh.
+type Unsubscribe func()
+
+// bus is the event bus of the mesh. Every event emitted by the mesh passes
+// through the bus, which delivers it to each of its subscribers in the order
+// the events were emitted.
+type bus struct {
+	// order serializes the fan-out of published events, so that every
+	// subscriber receives events in the order they were published
+	order sync.Mutex
+
+	// sequence is the sequence number of the last published event, guarded
+	// by order, so that sequence numbers follow the order of publishing
+	sequence uint64
+
+	mu         sync.RWMutex
+	inflight   atomic.Int64
+	mode       DeliveryMode
+	queueSize  int
+	subs       map[string][]*subscription
+	patterns   []*subscription
+	nextID     uint64
+	history    []Event
+	middleware []EventMiddleware
+
+	deadLetters    []DeadLetter
+	deadLetterSink func(DeadLetter)
+}
+
+// SubscribeOption configures a subscription to the event bus of the mesh.
+type SubscribeOption func(*subscribeOptions)
+
+type subscribeOptions struct {
+	replay    bool
+	source    string
+	name      string
+	mode      *DeliveryMode
+	queueSize int
+	attempts  int
+	backoff   time.Duration
+}
+
+// WithReplay replays the events retained in the event history of the mesh to
+// the new subscriber, in order, before any events emitted after subscribing.
+func WithReplay() SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.replay = true
+	}
+}
+
+// WithSource only delivers events whose envelope names the given service as
+// their source.
+func WithSource(name string) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.source = name
+	}
+}
+
+// WithName names the subscriber. The name is passed to event middleware as
+// the Handler of each event delivered to the subscriber.
+func WithName(name string) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.name = name
+	}
+}
+
+// Event is a single event which passed through the event bus of the mesh.
+type Event struct {
+	// Name is the name of the event, for typed events this is the topic
+	// the type is published on.
+	Name string
+
+	// Args are the arguments the event was emitted with.
+	Args []any
+
+	// Envelope is the metadata of the event.
+	Envelope Envelope
+
+	// Handler identifies the subscriber an event is being delivered to. It
+	// is only set while the event passes through event middleware on its
+	// way to a subscriber, and is empty while the event is being published.
+	Handler string
+}
+
+// subscription is a single subscriber of a topic of the bus. Events are
+// queued for each subscription and delivered one at a time, so a subscriber
+// sees events in order while a slow subscriber does not hold up the others.
+// How events are queued depends on the DeliveryMode of the subscription.
+type subscription struct {
+	id        uint64
+	name      string
+	topic     string
+	pattern   bool
+	source    string
+	fn        func(Event) error
+	attempts  int
+	backoff   time.Duration
+	mode      DeliveryMode
+	queueSize int
+	mu        sync.Mutex
+	space     *sync.Cond // signalled when a queued event is taken
+	queue     []delivery
+	running   bool
+	replaying bool       // live events are held back while history is replayed
+	held      []delivery // live events held back for a synchronous subscriber
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// matches returns true if the subscription receives the given event.
+func (sub *subscription) matches(e Event) bool {
+	if sub.source != "" && sub.source != e.Envelope.Source {
+		return false
+	}
+
+	if sub.pattern {
+		return matchPattern(sub.topic, e.Name)
+	}
+
+	return sub.topic == e.Name
+}
+
+type delivery struct {
+	event Event
+	wg    *sync.WaitGroup
+}
+
+// subscribe adds a subscriber for a topic of the bus.
+func (m *mesh) subscribe(topic string, fn func(Event), opts ...SubscribeOption) Unsubscribe {
+	return m.subscribeErr(topic, ignoreErr(fn), opts...)
+}
+
+// subscribeErr adds a subscriber for a topic of the bus, whose handler may
+// fail.
+func (m *mesh) subscribeErr(topic string, fn func(Event) error, opts ...SubscribeOption) Unsubscribe {
+	return m.addSubscription(&subscription{topic: topic, fn: fn}, opts)
+}
+
+// subscribePattern adds a subscriber for every topic of the bus which
+// matches the pattern.
+func (m *mesh) subscribePattern(pattern string, fn func(Event), opts ...SubscribeOption) Unsubscribe {
+	return m.subscribePatternErr(pattern, ignoreErr(fn), opts...)
+}
+
+// subscribePatternErr adds a subscriber for every topic of the bus which
+// matches the pattern, whose handler may fail.
+func (m *mesh) subscribePatternErr(pattern string, fn func(Event) error, opts ...SubscribeOption) Unsubscribe {
+	return m.addSubscription(&subscription{topic: pattern, pattern: true, fn: fn}, opts)
+}
+
+// ignoreErr adapts a handler which cannot fail.
+func ignoreErr(fn func(Event)) func(Event) error {
+	return func(e Event) error {
+		fn(e)
+		return nil
+	}
+}
+
+func (m *mesh) addSubscription(sub *subscription, opts []SubscribeOption) Unsubscribe {
+	var options subscribeOptions
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// no event can be published while the subscription is added, so the
+	// replayed history is queued before any live events
+	m.bus.order.Lock()
+	m.bus.mu.Lock()
+
+	if m.bus.subs == nil {
+		m.bus.subs = make(map[string][]*subscription)
+	}
+
+	m.bus.nextID++
+	sub.id = m.bus.nextID
+	sub.source = options.source
+	sub.name = options.name
+	sub.attempts = max(options.attempts, 1)
+	sub.backoff = options.backoff
+	sub.mode = m.bus.mode
+	sub.queueSize = m.bus.queueSize
+	sub.space = sync.NewCond(&sub.mu)
+
+	if options.mode != nil {
+		sub.mode = *options.mode
+		sub.queueSize = options.queueSize
+	}
+
+	if sub.name == "" {
+		sub.name = fmt.Sprintf("subscriber %d", sub.id)
+	}
+
+	if sub.pattern {
+		m.bus.patterns = append(m.bus.patterns, sub)
+	} else {
+		m.bus.subs[sub.topic] = append(m.bus.subs[sub.topic], sub)
+	}
+
+	var replay []Event
+	if options.replay {
+		replay = m.historyFor(sub)
+	}
+
+	// synchronous subscribers are handed the history once the bus is
+	// unlocked, so that their handlers may publish events themselves. Live
+	// events are held back until then, so they never precede the history.
+	sub.replaying = sub.mode == DeliverySync && len(replay) > 0
+
+	m.bus.mu.Unlock()
+
+	if sub.mode != DeliverySync {
+		m.replay(sub, replay)
+	}
+
+	m.bus.order.Unlock()
+
+	if sub.replaying {
+		m.replay(sub, replay)
+		m.releaseHeld(sub)
+	}
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			m.unsubscribe(sub)
+		})
+	}
+}
+
+// replay delivers past events to a new subscriber.
+func (m *mesh) replay(sub *subscription, events []Event) {
+	var wg sync.WaitGroup
+
+	for _, e := range events {
+		wg.Add(1)
+
+		if sub.mode == DeliverySync {
+			m.bus.inflight.Add(1)
+			m.deliver(sub, delivery{event: e, wg: &wg})
+
+			continue
+		}
+
+		m.enqueue(sub, delivery{event: e, wg: &wg})
+	}
+}
+
+func (m *mesh) unsubscribe(sub *subscription) {
+	m.bus.mu.Lock()
+	defer m.bus.mu.Unlock()
+
+	if sub.pattern {
+		m.bus.patterns = removeSubscription(m.bus.patterns, sub)
+		return
+	}
+
+	m.bus.subs[sub.topic] = removeSubscription(m.bus.subs[sub.topic], sub)
+
+	if len(m.bus.subs[sub.topic]) == 0 {
+		delete(m.bus.subs, sub.topic)
+	}
+}
+
+func removeSubscription(list []*subscription, sub *subscription) []*subscription {
+	for i, candidate := range list {
+		if candidate == sub {
+			return append(list[:i:i], list[i+1:]...)
+		}
+	}
+
+	return list
+}
+
+// publish queues an event for every subscriber of its topic. The returned
+// WaitGroup completes once every subscriber has handled the event, or the
+// event was dropped for the subscriber.
+//
+// Subscribers with synchronous delivery handle the event before publish
+// returns, while subscribers with blocking delivery make publish wait until
+// their queue has room for the event.
+//
+// The sequence number of the event is assigned while the order of the bus is
+// held, so the history and every subscriber see increasing sequence numbers.
+func (m *mesh) publish(e *Event) *sync.WaitGroup {
+	var wg sync.WaitGroup
+
+	m.bus.order.Lock()
+
+	e.Envelope.Sequence = m.nextSequence()
+
+	m.bus.mu.Lock()
+	m.recordHistory(*e)
+
+	var targets []*subscription
+
+	for _, sub := range m.bus.subs[e.Name] {
+		if sub.matches(*e) {
+			targets = append(targets, sub)
+		}
+	}
+
+	for _, sub := range m.bus.patterns {
+		if sub.matches(*e) {
+			targets = append(targets, sub)
+		}
+	}
+
+	m.bus.mu.Unlock()
+
+	var synchronous []*subscription
+
+	for _, sub := range targets {
+		if sub.mode == DeliverySync {
+			synchronous = append(synchronous, sub)
+			continue
+		}
+
+		wg.Add(1)
+		m.enqueue(sub, delivery{event: *e, wg: &wg})
+	}
+
+	m.bus.order.Unlock()
+
+	for _, sub := range synchronous {
+		wg.Add(1)
+		m.deliverSync(sub, delivery{event: *e, wg: &wg})
+	}
+
+	return &wg
+}
+
+// nextSequence yields the sequence number of the next published event. The
+// order lock of the bus must be held.
+func (m *mesh) nextSequence() uint64 {
+	m.bus.sequence++
+
+	return m.bus.sequence
+}
+
+// deliverSync delivers an event to a synchronous subscriber on the calling
+// goroutine. While the history is replayed to the subscriber, the event is
+// held back instead, and delivered once the replay has finished.
+func (m *mesh) deliverSync(sub *subscription, d delivery) {
+	m.bus.inflight.Add(1)
+
+	sub.mu.Lock()
+
+	if sub.replaying {
+		sub.held = append(sub.held, d)
+		sub.mu.Unlock()
+
+		return
+	}
+
+	sub.mu.Unlock()
+
+	m.deliver(sub, d)
+}
+
+// releaseHeld delivers the live events which were held back while the
+// history was replayed to a synchronous subscriber, in order.
+func (m *mesh) releaseHeld(sub *subscription) {
+	for {
+		sub.mu.Lock()
+
+		if len(sub.held) == 0 {
+			sub.replaying = false
+			sub.mu.Unlock()
+
+			return
+		}
+
+		d := sub.held[0]
+		sub.held = sub.held[1:]
+
+		sub.mu.Unlock()
+
+		m.deliver(sub, d)
+	}
+}
+
+// enqueue queues an event for delivery to a subscriber, according to the
+// delivery mode of the subscriber. It reports whether the event was queued,
+// rather than dropped because the queue of the subscriber is full.
+func (m *mesh) enqueue(sub *subscription, d delivery) bool {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	m.bus.inflight.Add(1)
+
+	if full := sub.queueSize > 0 && len(sub.queue) >= sub.queueSize; full {
+		switch sub.mode {
+		case DeliveryBlock:
+			for sub.queueSize > 0 && len(sub.queue) >= sub.queueSize {
+				sub.space.Wait()
+			}
+		case DeliveryDropOldest:
+			oldest := sub.queue[0]
+			sub.queue = sub.queue[1:]
+			m.drop(sub, oldest)
+		default:
+			m.drop(sub, d)
+			return false
+		}
+	}
+
+	sub.queue = append(sub.queue, d)
+
+	if !sub.running {
+		sub.running = true
+
+		go m.drain(sub)
+	}
+
+	return true
+}
+
+// drop discards an event which was not delivered to a subscriber.
+func (m *mesh) drop(sub *subscription, d delivery) {
+	if sub.dropped.Add(1) == 1 {
+		m.logger.Warn("event queue full, dropping events", "handler", sub.name, "topic", sub.topic)
+	}
+
+	d.wg.Done()
+	m.bus.inflight.Add(-1)
+}
+
+// drain delivers the queued events of a subscription until its queue is
+// empty.
+func (m *mesh) drain(sub *subscription) {
+	for {
+		sub.mu.Lock()
+
+		if len(sub.queue) == 0 {
+			sub.running = false
+			sub.mu.Unlock()
+
+			return
+		}
+
+		d := sub.queue[0]
+		sub.queue = sub.queue[1:]
+		sub.space.Signal()
+
+		sub.mu.Unlock()
+
+		m.deliver(sub, d)
+	}
+}
+
+// deliver invokes the handler of a subscription with a single event. When
+// the handler fails, it is retried according to the subscription, and the
+// event becomes a dead letter once every attempt has failed. A failing
+// handler does not affect other subscribers.
+func (m *mesh) deliver(sub *subscription, d delivery) {
+	defer m.bus.inflight.Add(-1)
+	defer sub.delivered.Add(1)
+	defer d.wg.Done()
+
+	e := d.event
+	e.Handler = sub.name
+
+	backoff := sub.backoff
+
+	for attempt := 1; ; attempt++ {
+		err := m.attempt(sub, e)
+		if err == nil {
+			return
+		}
+
+		if attempt >= sub.attempts {
+			m.deadLetter(DeadLetter{
+				Event:    d.event,
+				Handler:  sub.name,
+				Err:      err,
+				Attempts: attempt,
+				Time:     time.Now(),
+			})
+
+			return
+		}
+
+		m.logger.Debug("retrying event handler", "event", e.Name, "handler", sub.name, "attempt", attempt, "error", err)
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// SubscribeAll binds a handler to the event bus of the mesh which receives
+// every event passing through the bus, in the order they were emitted. The
+// returned function removes the subscription.
+//
+// Like SubscribePattern, it panics with ErrUnsupportedMesh for a Mesh other
+// than the one created with New.
+func SubscribeAll(m Mesh, fn func(Event), opts ...SubscribeOption) Unsubscribe {
+	return SubscribePattern(m, "*", fn, opts...)
+}
+
+// SubscribePatternErr is like SubscribePattern, for a handler which may fail.
+// An event the handler fails to handle, after any retries set with
+// WithRetry, is passed to the dead-letter sink of the mesh and reported with
+// EventHandlerFailed.
+func SubscribePatternErr(m Mesh, pattern string, fn func(Event) error, opts ...SubscribeOption) Unsubscribe {
+	if impl, ok := meshOf(m); ok {
+		return impl.subscribePatternErr(pattern, fn, opts...)
+	}
+
+	// without the event bus, only a pattern naming a single event can be
+	// bound to the raw event emitter of the mesh
+	if !strings.ContainsAny(pattern, "*?") {
+		return subscribeRaw(m, pattern, fn)
+	}
+
+	panic(fmt.Errorf("%w: pattern subscription %q", ErrUnsupportedMesh, pattern))
+}
+
+// SubscribePattern binds a handler to the event bus of the mesh which
+// receives every event whose name matches the pattern, in the order they
+// were emitted. In a pattern, '*' matches any sequence of characters and '?'
+// matches any single character, so "service *" matches every built-in
+// service event, and "orders.*" matches every event beneath "orders.".
+//
+// Combined with WithSource, this can be used to receive every event
+// originating from a particular service. The returned function removes the
+// subscription.
+//
+// Matching patterns requires the event bus of a Mesh created with New. For
+// any other Mesh, a pattern without wildcards is bound to the raw event
+// emitter yielded by Events, like Subscribe does, and any other pattern
+// panics with ErrUnsupportedMesh.
+func SubscribePattern(m Mesh, pattern string, fn func(Event), opts ...SubscribeOption) Unsubscribe {
+	return SubscribePatternErr(m, pattern, ignoreErr(fn), opts...)
+}
+
+// meshOf yields the mesh behind a Mesh, which is either the mesh itself or a
+// Mesh yielding the event emitter of the mesh.
+func meshOf(m Mesh) (*mesh, bool) {
+	if impl, ok := m.(*mesh); ok {
+		return impl, true
+	}
+
+	if emitter, ok := m.Events().(meshEmitter); ok {
+		return emitter.mesh, true
+	}
+
+	return nil, false
+}
+
+// matchPattern reports whether the name matches the pattern, where '*'
+// matches any sequence of characters and '?' matches any single character.
+func matchPattern(pattern, name string) bool {
+	p, n := []rune(pattern), []rune(name)
+
+	// the position of the last '*' in the pattern, and where in the name to
+	// resume from when backtracking to it
+	star, resume := -1, 0
+
+	i, j := 0, 0
+
+	for j < len(n) {
+		switch {
+		case i < len(p) && (p[i] == '?' || p[i] == n[j]):
+			i++
+			j++
+		case i < len(p) && p[i] == '*':
+			star, resume = i, j
+			i++
+		case star >= 0:
+			resume++
+			i, j = star+1, resume
+		default:
+			return false
+		}
+	}
+
+	for i < len(p) && p[i] == '*' {
+		i++
+	}
+
+	return i == len(p)
+}
+
+// joinWaitGroups yields a single WaitGroup which completes once all of the
+// given WaitGroups have completed.
+func joinWaitGroups(list ...*sync.WaitGroup) *sync.WaitGroup {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		for _, pending := range list {
+			pending.Wait()
+		}
+
+		wg.Done()
+	}()
+
+	return &wg
+}
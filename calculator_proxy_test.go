@@ -0,0 +1,41 @@
+// Code generated by servicemesh-proxy. DO NOT EDIT.
+
+package servicemesh
+
+import (
+	"context"
+)
+
+// calculatorProxy implements calculator by calling the "Calculator" service
+// through a RemoteService.
+type calculatorProxy struct {
+	*RemoteService
+}
+
+// newCalculatorProxy creates a proxy for the "Calculator" service,
+// exported by the ServiceExporter listening on the given network and
+// address.
+func newCalculatorProxy(network, address string) *calculatorProxy {
+	return &calculatorProxy{NewRemoteService("Calculator", network, address)}
+}
+
+// Add calls the Add method of the remote service.
+func (p *calculatorProxy) Add(a0 int, a1 int) (int, error) {
+	var r0 int
+
+	err := p.Call(context.Background(), "Add", &r0, a0, a1)
+
+	return r0, err
+}
+
+// DivMod calls the DivMod method of the remote service.
+func (p *calculatorProxy) DivMod(ctx context.Context, a1 int, a2 int) (int, int) {
+	var r0 int
+	var r1 int
+
+	if err := p.CallResults(ctx, "DivMod", []any{&r0, &r1}, a1, a2); err != nil {
+		p.LogCallError("DivMod", err)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,83 @@
+package servicemesh
+
+import (
+	"context"
+)
+
+// NewChild creates a child mesh which is scoped beneath this mesh. The child
+// is added to this mesh as a service, so it is shut down when this mesh
+// shuts down. Services within the child mesh can resolve their dependencies
+// from the services of the child and of all of its ancestors.
+func (m *mesh) NewChild(name string) Mesh {
+	m.Init(nil) // always ensure service mesh is init
+
+	child := newMesh(name)
+	child.parent = m
+	m.inheritLogging(child)
+	m.copyLogLevels(child)
+
+	// just like the root mesh, a child mesh binds handlers to its own events
+	child.Add(child)
+
+	m.Add(child)
+
+	return child
+}
+
+// SetEventBubbling enables or disables the re-emitting of the events of a
+// child mesh on the event bus of its parent. This has no effect on a mesh
+// which has no parent.
+func (m *mesh) SetEventBubbling(enabled bool) {
+	m.bubbleEvents = enabled
+}
+
+// OnShutdown ties the lifecycle of a child mesh to its parent. When the parent
+// mesh shuts down, it will shut down all of its children.
+func (m *mesh) OnShutdown() {
+	if m.parent == nil {
+		return
+	}
+
+	_ = m.Shutdown().Wait(context.Background())
+}
+
+// resolvableServices yields the services which are candidates for dependency
+// resolution. For a child mesh, this includes the services of every ancestor.
+// Services which report themselves unhealthy through HasHealth are left out.
+func (m *mesh) resolvableServices() (list []Service) {
+	for candidate := m; candidate != nil; candidate = candidate.parent {
+		for _, service := range candidate.Services() {
+			if isHealthy(service) {
+				list = append(list, service)
+			}
+		}
+	}
+
+	return list
+}
+
+// initializedServices yields the resolvable services which have been
+// initialized, so that a service is never handed a dependency whose Init has
+// not run yet.
+func (m *mesh) initializedServices() (list []Service) {
+	for candidate := m; candidate != nil; candidate = candidate.parent {
+		for _, service := range candidate.Services() {
+			if candidate.isReady(service) && isHealthy(service) {
+				list = append(list, service)
+			}
+		}
+	}
+
+	return list
+}
+
+// children yields the child meshes which have been created with NewChild.
+func (m *mesh) children() (list []*mesh) {
+	for _, service := range m.Services() {
+		if child, ok := service.(*mesh); ok && child != m && child.parent == m {
+			list = append(list, child)
+		}
+	}
+
+	return list
+}
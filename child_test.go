@@ -0,0 +1,86 @@
+package servicemesh
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChildMesh(t *testing.T) {
+	m := New("parent")
+	provider := &providerService{}
+	m.Add(provider).Wait(context.Background())
+
+	child := m.NewChild("child")
+	consumer := &dependentService{}
+	quitter := &shutdownRecorder{}
+
+	child.Add(quitter).Wait(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		child.Add(consumer).Wait(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("child service did not resolve its dependency from the parent")
+	}
+
+	if consumer.dependency.Load() != provider {
+		t.Error("unexpected dependency")
+	}
+
+	for _, service := range m.Services() {
+		if service == consumer {
+			t.Error("child services should not be visible in the parent")
+		}
+	}
+
+	m.Shutdown().Wait(context.Background())
+
+	if !quitter.shutdown.Load() {
+		t.Error("shutting down the parent should shut down the child")
+	}
+}
+
+// dependentService stores its dependency atomically, since it is resolved by
+// the dependency watcher while tests check it.
+type dependentService struct {
+	dependency atomic.Pointer[providerService]
+}
+
+func (d *dependentService) Init(_ Mesh) {}
+
+func (d *dependentService) Name() string { return "dependent" }
+
+func (d *dependentService) DependenciesResolved() bool {
+	return d.dependency.Load() != nil
+}
+
+func (d *dependentService) ResolveDependencies(services []Service) {
+	for _, service := range services {
+		if candidate, ok := service.(*providerService); ok {
+			d.dependency.Store(candidate)
+		}
+	}
+}
+
+type providerService struct{}
+
+func (p *providerService) Init(_ Mesh) {}
+
+func (p *providerService) Name() string { return "provider" }
+
+type shutdownRecorder struct {
+	shutdown atomic.Bool
+}
+
+func (s *shutdownRecorder) Init(_ Mesh) {}
+
+func (s *shutdownRecorder) Name() string { return "shutdown recorder" }
+
+func (s *shutdownRecorder) OnShutdown() { s.shutdown.Store(true) }
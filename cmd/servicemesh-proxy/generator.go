@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/printer"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const servicemeshPath = "github.com/gravestench/servicemesh"
+
+// generator writes the proxy of an interface declared in a file.
+type generator struct {
+	fset      *token.FileSet
+	file      *ast.File
+	typeName  string
+	service   string
+	qualifier string
+	imports   map[string]string // import path by name, of the source file
+	used      map[string]string // import path by name, of the generated file
+	buf       bytes.Buffer
+}
+
+func newGenerator(fset *token.FileSet, file *ast.File, typeName, service string) *generator {
+	g := &generator{
+		fset:     fset,
+		file:     file,
+		typeName: typeName,
+		service:  service,
+		imports:  make(map[string]string),
+		used:     make(map[string]string),
+	}
+
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+
+		g.imports[name] = importPath
+	}
+
+	// the proxy may be generated within the servicemesh package itself
+	switch {
+	case file.Name.Name == "servicemesh":
+	case g.importName(servicemeshPath) != "":
+		g.qualifier = g.importName(servicemeshPath) + "."
+		g.used[g.importName(servicemeshPath)] = servicemeshPath
+	default:
+		g.qualifier = "servicemesh."
+		g.used["servicemesh"] = servicemeshPath
+	}
+
+	return g
+}
+
+func (g *generator) importName(importPath string) string {
+	for name, candidate := range g.imports {
+		if candidate == importPath {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// interfaceProxy yields the source of the proxy of the interface.
+func (g *generator) interfaceProxy(iface *ast.InterfaceType) ([]byte, error) {
+	proxy := g.typeName + "Proxy"
+
+	// the proxy of an unexported interface is unexported as well
+	constructor := "New" + proxy
+	if !ast.IsExported(g.typeName) {
+		constructor = "new" + strings.ToUpper(proxy[:1]) + proxy[1:]
+	}
+
+	g.printf("// %s implements %s by calling the %q service\n", proxy, g.typeName, g.service)
+	g.printf("// through a %sRemoteService.\n", g.qualifier)
+	g.printf("type %s struct {\n\t*%sRemoteService\n}\n\n", proxy, g.qualifier)
+
+	g.printf("// %s creates a proxy for the %q service,\n", constructor, g.service)
+	g.printf("// exported by the ServiceExporter listening on the given network and\n")
+	g.printf("// address.\n")
+	g.printf("func %s(network, address string) *%s {\n", constructor, proxy)
+	g.printf("\treturn &%s{%sNewRemoteService(%q, network, address)}\n}\n", proxy, g.qualifier, g.service)
+
+	for _, field := range iface.Methods.List {
+		fn, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			return nil, fmt.Errorf("%s: embedded interfaces are not supported", g.fset.Position(field.Pos()))
+		}
+
+		for _, name := range field.Names {
+			g.method(proxy, name.Name, fn)
+		}
+	}
+
+	var out bytes.Buffer
+
+	out.WriteString("// Code generated by servicemesh-proxy. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", g.file.Name.Name)
+	out.WriteString("import (\n")
+
+	names := make([]string, 0, len(g.used))
+	for name := range g.used {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	// the standard library first, as goimports groups them
+	for _, std := range []bool{true, false} {
+		if !std {
+			out.WriteString("\n")
+		}
+
+		for _, name := range names {
+			importPath := g.used[name]
+			if isStd(importPath) != std {
+				continue
+			}
+
+			if path.Base(importPath) == name {
+				fmt.Fprintf(&out, "\t%q\n", importPath)
+			} else {
+				fmt.Fprintf(&out, "\t%s %q\n", name, importPath)
+			}
+		}
+	}
+
+	out.WriteString(")\n\n")
+	out.Write(g.buf.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+// method writes a method of the proxy.
+func (g *generator) method(proxy, name string, fn *ast.FuncType) {
+	var params, args []string
+
+	ctx := "context.Background()"
+	variadic := ""
+
+	for i, typ := range expand(fn.Params) {
+		param := fmt.Sprintf("a%d", i)
+
+		if i == 0 && g.isContext(typ) {
+			param, ctx = "ctx", "ctx"
+			params = append(params, "ctx "+g.expr(typ))
+
+			continue
+		}
+
+		if ellipsis, ok := typ.(*ast.Ellipsis); ok {
+			variadic = param
+			params = append(params, param+" ..."+g.expr(ellipsis.Elt))
+
+			continue
+		}
+
+		params = append(params, param+" "+g.expr(typ))
+		args = append(args, param)
+	}
+
+	results := expand(fn.Results)
+
+	var values []string
+	returnsErr := false
+
+	for i, typ := range results {
+		if i == len(results)-1 && g.expr(typ) == "error" {
+			returnsErr = true
+			continue
+		}
+
+		values = append(values, g.expr(typ))
+	}
+
+	signature := strings.Join(values, ", ")
+	if returnsErr {
+		signature = strings.Join(append(append([]string(nil), values...), "error"), ", ")
+	}
+
+	if len(results) > 1 {
+		signature = "(" + signature + ")"
+	}
+
+	g.printf("\n// %s calls the %s method of the remote service.\n", name, name)
+	g.printf("func (p *%s) %s(%s) %s {\n", proxy, name, strings.Join(params, ", "), signature)
+
+	if ctx != "ctx" {
+		g.used["context"] = "context"
+	}
+
+	callArgs := strings.Join(append([]string{""}, args...), ", ")
+
+	if variadic != "" {
+		g.printf("\targs := []any{%s}\n", strings.Join(args, ", "))
+		g.printf("\tfor _, arg := range %s {\n\t\targs = append(args, arg)\n\t}\n\n", variadic)
+		callArgs = ", args..."
+	}
+
+	var replies []string
+
+	for i, value := range values {
+		g.printf("\tvar r%d %s\n", i, value)
+		replies = append(replies, fmt.Sprintf("&r%d", i))
+	}
+
+	var call string
+
+	switch len(values) {
+	case 0:
+		call = fmt.Sprintf("p.Call(%s, %q, nil%s)", ctx, name, callArgs)
+	case 1:
+		call = fmt.Sprintf("p.Call(%s, %q, %s%s)", ctx, name, replies[0], callArgs)
+	default:
+		call = fmt.Sprintf("p.CallResults(%s, %q, []any{%s}%s)", ctx, name, strings.Join(replies, ", "), callArgs)
+	}
+
+	returned := make([]string, 0, len(values)+1)
+	for i := range values {
+		returned = append(returned, fmt.Sprintf("r%d", i))
+	}
+
+	sep := ""
+	if len(values) > 0 || variadic != "" {
+		sep = "\n"
+	}
+
+	switch {
+	case returnsErr && len(values) == 0:
+		g.printf("%s\treturn %s\n", sep, call)
+	case returnsErr:
+		g.printf("%s\terr := %s\n\n", sep, call)
+		g.printf("\treturn %s, err\n", strings.Join(returned, ", "))
+	default:
+		// without an error result, the failure of the call cannot be
+		// returned, so it is logged, and the zero values are returned
+		g.printf("%s\tif err := %s; err != nil {\n", sep, call)
+		g.printf("\t\tp.LogCallError(%q, err)\n\t}\n", name)
+
+		if len(values) > 0 {
+			g.printf("\n\treturn %s\n", strings.Join(returned, ", "))
+		}
+	}
+
+	g.printf("}\n")
+}
+
+// isStd reports whether an import path belongs to the standard library.
+func isStd(importPath string) bool {
+	return !strings.Contains(strings.Split(importPath, "/")[0], ".")
+}
+
+// isContext reports whether a type is context.Context.
+func (g *generator) isContext(typ ast.Expr) bool {
+	sel, ok := typ.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+
+	pkg, ok := sel.X.(*ast.Ident)
+
+	return ok && g.imports[pkg.Name] == "context" && sel.Sel.Name == "Context"
+}
+
+// expr prints a type, recording the imports it refers to. Types declared in
+// the package of the interface are printed as is, since the proxy is
+// generated into the same package.
+func (g *generator) expr(typ ast.Expr) string {
+	ast.Inspect(typ, func(node ast.Node) bool {
+		if sel, ok := node.(*ast.SelectorExpr); ok {
+			if pkg, ok := sel.X.(*ast.Ident); ok {
+				if importPath, found := g.imports[pkg.Name]; found {
+					g.used[pkg.Name] = importPath
+				}
+			}
+		}
+
+		return true
+	})
+
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, g.fset, typ)
+
+	return buf.String()
+}
+
+// expand yields the type of every parameter or result of a list, repeating
+// the type of grouped names such as (a, b int).
+func expand(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+
+	var list []ast.Expr
+
+	for _, field := range fields.List {
+		n := max(len(field.Names), 1)
+
+		for i := 0; i < n; i++ {
+			list = append(list, field.Type)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,113 @@
+// Command servicemesh-proxy generates a typed adapter which implements a Go
+// interface by forwarding every method to a servicemesh.RemoteService, so
+// that a service exported by a ServiceExporter in another process can be
+// used wherever the interface is expected.
+//
+// It is meant to be run by go generate, next to the declaration of the
+// interface:
+//
+//	//go:generate go run github.com/gravestench/servicemesh/cmd/servicemesh-proxy -type Billing
+//
+// This writes billing_proxy.go, declaring a BillingProxy type and a
+// NewBillingProxy(network, address) constructor. The name of the remote
+// service defaults to the name of the interface, and can be set with
+// -service.
+//
+// If the first parameter of a method is a context.Context, it is used for
+// the call. If the last result is an error, it reports the failure of the
+// call, such as ErrRemoteUnavailable or the error returned by the remote
+// method.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("servicemesh-proxy: ")
+
+	typeName := flag.String("type", "", "name of the interface to generate a proxy for")
+	service := flag.String("service", "", "name of the remote service (default: the name of the interface)")
+	output := flag.String("output", "", "output file (default: <type>_proxy.go)")
+	dir := flag.String("dir", ".", "directory of the package declaring the interface")
+
+	flag.Parse()
+
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *service == "" {
+		*service = *typeName
+	}
+
+	if *output == "" {
+		*output = strings.ToLower(*typeName) + "_proxy.go"
+	}
+
+	src, err := generate(*dir, *typeName, *service)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = os.WriteFile(filepath.Join(*dir, *output), src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate yields the source of the proxy for the named interface, declared
+// in one of the Go files of the directory.
+func generate(dir, typeName, service string) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range files {
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decl := range file.Decls {
+			iface, found := findInterface(decl, typeName)
+			if found {
+				return newGenerator(fset, file, typeName, service).interfaceProxy(iface)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("interface %s not found in %s", typeName, dir)
+}
+
+func findInterface(decl ast.Decl, name string) (*ast.InterfaceType, bool) {
+	gen, ok := decl.(*ast.GenDecl)
+	if !ok || gen.Tok != token.TYPE {
+		return nil, false
+	}
+
+	for _, spec := range gen.Specs {
+		ts := spec.(*ast.TypeSpec)
+		if ts.Name.Name != name {
+			continue
+		}
+
+		iface, ok := ts.Type.(*ast.InterfaceType)
+
+		return iface, ok
+	}
+
+	return nil, false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const billing = `package billing
+
+import (
+	"context"
+	"time"
+)
+
+type Billing interface {
+	Charge(ctx context.Context, order int) (string, error)
+	Refund(order int, reasons ...string) error
+	Due(orders []int) (time.Time, int, error)
+	Ping()
+}
+`
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "billing.go"), []byte(billing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := generate(dir, "Billing", "billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = parser.ParseFile(token.NewFileSet(), "billing_proxy.go", src, 0); err != nil {
+		t.Fatalf("expected valid Go source, got %v:\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		`"github.com/gravestench/servicemesh"`,
+		`"time"`,
+		`func NewBillingProxy(network, address string) *BillingProxy`,
+		`servicemesh.NewRemoteService("billing", network, address)`,
+		`err := p.Call(ctx, "Charge", &r0, a1)`,
+		`return p.Call(context.Background(), "Refund", nil, args...)`,
+		`err := p.CallResults(context.Background(), "Due", []any{&r0, &r1}, a0)`,
+		`if err := p.Call(context.Background(), "Ping", nil); err != nil {`,
+		`p.LogCallError("Ping", err)`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("expected the proxy to contain %s, got:\n%s", want, src)
+		}
+	}
+
+	if _, err = generate(dir, "Missing", "missing"); err == nil {
+		t.Errorf("expected a missing interface to be reported")
+	}
+}
@@ -0,0 +1,94 @@
+package servicemesh
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"reflect"
+)
+
+// Codec encodes and decodes the messages exchanged between processes, such
+// as the events forwarded by an EventBridge.
+type Codec interface {
+	// NewEncoder returns an encoder writing a stream of messages to w.
+	NewEncoder(w io.Writer) Encoder
+
+	// NewDecoder returns a decoder reading a stream of messages from r.
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder writes messages to a stream.
+type Encoder interface {
+	Encode(v any) error
+}
+
+// Decoder reads messages from a stream.
+type Decoder interface {
+	Decode(v any) error
+}
+
+var (
+	// JSONCodec encodes messages as JSON. Arguments are decoded as generic
+	// JSON values (eg. map[string]any, float64), except for typed events
+	// whose type is known to the receiving process, which are restored to
+	// their type.
+	JSONCodec Codec = jsonCodec{}
+
+	// GobCodec encodes messages with encoding/gob. Argument types other
+	// than the basic types must be registered with gob.Register, in both
+	// processes.
+	GobCodec Codec = gobCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
+func (jsonCodec) NewDecoder(r io.Reader) Decoder { return json.NewDecoder(r) }
+
+type gobCodec struct{}
+
+func (gobCodec) NewEncoder(w io.Writer) Encoder { return gob.NewEncoder(w) }
+func (gobCodec) NewDecoder(r io.Reader) Decoder { return gob.NewDecoder(r) }
+
+func init() {
+	gob.Register(serviceRef{})
+}
+
+// portableArg yields the form of an argument which can be sent to another
+// process: services are sent by name and ID, and errors by their message.
+func (m *mesh) portableArg(arg any) any {
+	switch v := arg.(type) {
+	case Service:
+		return serviceRef{Service: v.Name(), ID: m.ServiceID(v)}
+	case error:
+		return v.Error()
+	}
+
+	return arg
+}
+
+// restoreTypedArg converts the argument of a typed event, received in a
+// generic form, back to the type of the event.
+func restoreTypedArg(topic string, arg any) any {
+	found, ok := typedTopics.Load(topic)
+	if !ok {
+		return arg
+	}
+
+	typ := found.(reflect.Type)
+	if reflect.TypeOf(arg) == typ {
+		return arg
+	}
+
+	data, err := json.Marshal(arg)
+	if err != nil {
+		return arg
+	}
+
+	ptr := reflect.New(typ)
+	if err = json.Unmarshal(data, ptr.Interface()); err != nil {
+		return arg
+	}
+
+	return ptr.Elem().Interface()
+}
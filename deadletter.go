@@ -0,0 +1,125 @@
+package servicemesh
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultDeadLetterRetention = 256
+
+// DeadLetter is an event which a subscriber failed to handle, because its
+// handler returned an error or panicked on every attempt.
+type DeadLetter struct {
+	// Event is the event which was not handled.
+	Event Event
+
+	// Handler names the subscriber which failed to handle the event.
+	Handler string
+
+	// Err is the error of the last attempt to handle the event. A panic in
+	// the handler is reported as ErrHandlerPanicked.
+	Err error
+
+	// Attempts is the number of times the handler was invoked.
+	Attempts int
+
+	// Time is when the last attempt failed.
+	Time time.Time
+}
+
+// WithRetry retries delivering an event to the subscriber when its handler
+// fails, up to the given number of attempts in total. The backoff is the
+// delay before the first retry, and doubles with each following retry.
+//
+// Retries are made by the goroutine delivering events to the subscriber, so
+// no further events are delivered to the subscriber while it backs off. For
+// subscribers with DeliverySync, the publisher waits out the backoff.
+func WithRetry(attempts int, backoff time.Duration) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.attempts = max(attempts, 1)
+		o.backoff = backoff
+	}
+}
+
+// SetDeadLetterSink sets a function which is called with every event a
+// subscriber failed to handle. The sink is called from the goroutine which
+// delivered the event, and should not block.
+func (m *mesh) SetDeadLetterSink(sink func(DeadLetter)) {
+	m.bus.mu.Lock()
+	defer m.bus.mu.Unlock()
+
+	m.bus.deadLetterSink = sink
+}
+
+// DeadLetters returns the most recent events which subscribers failed to
+// handle, oldest first.
+func (m *mesh) DeadLetters() (list []DeadLetter) {
+	m.bus.mu.RLock()
+	defer m.bus.mu.RUnlock()
+
+	return append(list, m.bus.deadLetters...)
+}
+
+// attempt delivers an event to a subscriber once, passing it through the
+// event middleware of the mesh. A panic within the handler or middleware is
+// reported as an error.
+func (m *mesh) attempt(sub *subscription, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrHandlerPanicked, r)
+		}
+	}()
+
+	m.withMiddleware(func(e Event) {
+		err = sub.fn(e)
+	})(e)
+
+	return err
+}
+
+// deadLetter records an event which a subscriber failed to handle, passes
+// it to the dead-letter sink, and emits EventHandlerFailed.
+func (m *mesh) deadLetter(letter DeadLetter) {
+	m.bus.mu.Lock()
+
+	if len(m.bus.deadLetters) >= defaultDeadLetterRetention {
+		copy(m.bus.deadLetters, m.bus.deadLetters[1:])
+		m.bus.deadLetters = m.bus.deadLetters[:len(m.bus.deadLetters)-1]
+	}
+
+	m.bus.deadLetters = append(m.bus.deadLetters, letter)
+	sink := m.bus.deadLetterSink
+
+	m.bus.mu.Unlock()
+
+	if sink != nil {
+		sink(letter)
+	}
+
+	// a failure to handle a failure is only logged, so that a failing
+	// handler of EventHandlerFailed does not fail forever
+	if letter.Event.Name == EventHandlerFailed {
+		m.logger.Error("event handler failed", "event", letter.Event.Name, "handler", letter.Handler, "error", letter.Err)
+		return
+	}
+
+	// the failure is published off the delivery goroutine, which a publisher
+	// may be waiting on for room in the queue of a blocking subscriber. It
+	// counts as in flight, so that shutdown waits for it.
+	m.bus.inflight.Add(1)
+
+	go func() {
+		defer m.bus.inflight.Add(-1)
+
+		Publish(m, HandlerFailed{
+			Event:    letter.Event.Name,
+			Handler:  letter.Handler,
+			Err:      letter.Err,
+			Attempts: letter.Attempts,
+		}, Envelope{
+			Source:        m.name,
+			SourceID:      m.ServiceID(m),
+			CorrelationID: letter.Event.Envelope.CorrelationID,
+		})
+	}()
+}
@@ -0,0 +1,115 @@
+package servicemesh
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeadLetters(t *testing.T) {
+	m := New()
+
+	sunk := make(chan DeadLetter, 10)
+	m.SetDeadLetterSink(func(letter DeadLetter) {
+		sunk <- letter
+	})
+
+	failed := make(chan HandlerFailed, 10)
+	Subscribe(m, func(e HandlerFailed) {
+		failed <- e
+	})
+
+	errDeclined := errors.New("payment declined")
+
+	var calls atomic.Int32
+
+	SubscribeErr(m, func(e orderPlaced) error {
+		calls.Add(1)
+		return errDeclined
+	}, WithName("billing"), WithRetry(3, time.Millisecond))
+
+	SubscribePattern(m, "explode", func(e Event) {
+		panic("boom")
+	}, WithName("exploder"))
+
+	_ = Publish(m, orderPlaced{ID: 1}).Wait(context.Background())
+
+	letter := <-sunk
+	if letter.Handler != "billing" || !errors.Is(letter.Err, errDeclined) || letter.Attempts != 3 {
+		t.Errorf("unexpected dead letter: %+v", letter)
+	}
+
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected the handler to be attempted 3 times, got %d", got)
+	}
+
+	if e := <-failed; e.Handler != "billing" || e.Attempts != 3 {
+		t.Errorf("unexpected handler failed event: %+v", e)
+	}
+
+	_ = m.Emit("explode").Wait(context.Background())
+
+	letter = <-sunk
+	if letter.Handler != "exploder" || !errors.Is(letter.Err, ErrHandlerPanicked) {
+		t.Errorf("expected the panic to be a dead letter, got %+v", letter)
+	}
+
+	<-failed
+
+	if n := len(m.DeadLetters()); n != 2 {
+		t.Errorf("expected 2 retained dead letters, got %d", n)
+	}
+}
+
+func TestFailingFailureHandler(t *testing.T) {
+	m := New()
+
+	var calls atomic.Int32
+
+	SubscribeErr(m, func(e HandlerFailed) error {
+		calls.Add(1)
+		return errors.New("alerting unavailable")
+	})
+
+	SubscribePatternErr(m, "work", func(e Event) error {
+		return errors.New("failed")
+	})
+
+	_ = m.Emit("work").Wait(context.Background())
+
+	time.Sleep(50 * time.Millisecond)
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected a failing failure handler not to cause further failures, got %d calls", got)
+	}
+}
+
+func TestDeadLettersWithBlockingDelivery(t *testing.T) {
+	m := New()
+
+	SubscribePatternErr(m, "work", func(e Event) error {
+		return errors.New("failed")
+	}, WithDelivery(DeliveryBlock, 1))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 20; i++ {
+			m.Emit("work")
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the publisher not to deadlock with a failing blocking subscriber")
+	}
+
+	waitFor(t, "every failure to be recorded", func() bool {
+		return len(m.DeadLetters()) == 20
+	})
+}
@@ -0,0 +1,165 @@
+package servicemesh
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultShutdownTimeout = 10 * time.Second
+
+// DeliveryMode determines how events are delivered to a subscriber of the
+// event bus of the mesh.
+type DeliveryMode int
+
+const (
+	// DeliveryAsync queues events for the subscriber, which handles them in
+	// the background. When the queue of the subscriber is full, new events
+	// are dropped. This is the default delivery mode.
+	DeliveryAsync DeliveryMode = iota
+
+	// DeliverySync delivers events to the subscriber before the publishing
+	// call returns, on the goroutine of the publisher.
+	DeliverySync
+
+	// DeliveryDropOldest queues events for the subscriber like
+	// DeliveryAsync, but when the queue is full, the oldest queued event is
+	// dropped to make room for the new one.
+	DeliveryDropOldest
+
+	// DeliveryBlock queues events for the subscriber, and makes publishers
+	// wait while the queue of the subscriber is full. A subscriber with
+	// blocking delivery must not publish events from its handler, as it may
+	// end up waiting for itself. The EventHandlerFailed events the mesh
+	// emits for the subscriber are published separately, and do not block.
+	DeliveryBlock
+)
+
+// String returns the name of the delivery mode.
+func (d DeliveryMode) String() string {
+	switch d {
+	case DeliveryAsync:
+		return "async"
+	case DeliverySync:
+		return "sync"
+	case DeliveryDropOldest:
+		return "drop-oldest"
+	case DeliveryBlock:
+		return "block"
+	default:
+		return fmt.Sprintf("DeliveryMode(%d)", int(d))
+	}
+}
+
+// WithDelivery sets the delivery mode of a subscriber, and the number of
+// events which may be queued for it. A queue size of zero leaves the queue
+// unbounded. The queue size has no effect for DeliverySync.
+func WithDelivery(mode DeliveryMode, queueSize int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.mode = &mode
+		o.queueSize = max(queueSize, 0)
+	}
+}
+
+// QueueStats describes the event queue of a single subscriber of the event
+// bus of the mesh.
+type QueueStats struct {
+	// Subscriber is the name of the subscriber.
+	Subscriber string
+
+	// Topic is the topic or pattern the subscriber is subscribed to.
+	Topic string
+
+	// Mode is the delivery mode of the subscriber.
+	Mode DeliveryMode
+
+	// Depth is the number of events currently queued for the subscriber.
+	Depth int
+
+	// Capacity is the maximum number of events queued for the subscriber,
+	// zero if the queue is unbounded.
+	Capacity int
+
+	// Delivered is the number of events delivered to the subscriber.
+	Delivered uint64
+
+	// Dropped is the number of events which were dropped because the queue
+	// of the subscriber was full.
+	Dropped uint64
+}
+
+// SetEventDelivery sets the delivery mode and queue size for subscribers of
+// the event bus which do not set their own with WithDelivery. It applies to
+// subscribers added after the call.
+func (m *mesh) SetEventDelivery(mode DeliveryMode, queueSize int) {
+	m.bus.mu.Lock()
+	defer m.bus.mu.Unlock()
+
+	m.bus.mode = mode
+	m.bus.queueSize = max(queueSize, 0)
+}
+
+// SetShutdownTimeout sets how long Shutdown waits for the events queued on
+// the event bus to be delivered. A timeout of zero does not wait at all.
+func (m *mesh) SetShutdownTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.shutdownTimeout = d
+}
+
+// EventQueueStats returns the statistics of the event queue of every
+// subscriber of the event bus of the mesh.
+func (m *mesh) EventQueueStats() (list []QueueStats) {
+	m.bus.mu.RLock()
+	defer m.bus.mu.RUnlock()
+
+	for _, subs := range m.bus.subs {
+		for _, sub := range subs {
+			list = append(list, sub.stats())
+		}
+	}
+
+	for _, sub := range m.bus.patterns {
+		list = append(list, sub.stats())
+	}
+
+	return list
+}
+
+func (sub *subscription) stats() QueueStats {
+	sub.mu.Lock()
+	depth := len(sub.queue)
+	sub.mu.Unlock()
+
+	return QueueStats{
+		Subscriber: sub.name,
+		Topic:      sub.topic,
+		Mode:       sub.mode,
+		Depth:      depth,
+		Capacity:   sub.queueSize,
+		Delivered:  sub.delivered.Load(),
+		Dropped:    sub.dropped.Load(),
+	}
+}
+
+// drainEvents waits for the events queued on the event bus to be delivered,
+// up to the shutdown timeout of the mesh.
+func (m *mesh) drainEvents() error {
+	m.mu.RLock()
+	timeout := m.shutdownTimeout
+	m.mu.RUnlock()
+
+	deadline := time.Now().Add(timeout)
+
+	for m.bus.inflight.Load() > 0 {
+		if time.Now().After(deadline) {
+			m.logger.Warn("shutdown timed out with undelivered events", "pending", m.bus.inflight.Load())
+
+			return fmt.Errorf("%w: %d undelivered events", ErrShutdownTimeout, m.bus.inflight.Load())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	return nil
+}
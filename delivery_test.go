@@ -0,0 +1,150 @@
+package servicemesh
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeliveryModes(t *testing.T) {
+	m := New()
+
+	// a synchronous subscriber handles the event before Emit returns
+	var mu sync.Mutex
+	var handled []any
+
+	SubscribePattern(m, "sync", func(e Event) {
+		mu.Lock()
+		handled = append(handled, e.Args[0])
+		mu.Unlock()
+	}, WithDelivery(DeliverySync, 0))
+
+	m.Emit("sync", 1)
+
+	mu.Lock()
+	if len(handled) != 1 {
+		t.Errorf("expected the synchronous subscriber to handle the event before Emit returned, got %v", handled)
+	}
+	mu.Unlock()
+
+	// a slow subscriber with a bounded queue drops the newest or the oldest
+	// events once its queue is full
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+
+	var newest, oldest []any
+
+	SubscribePattern(m, "burst", func(e Event) {
+		started <- struct{}{}
+		<-release
+		mu.Lock()
+		newest = append(newest, e.Args[0])
+		mu.Unlock()
+	}, WithDelivery(DeliveryAsync, 2), WithName("newest"))
+
+	SubscribePattern(m, "burst", func(e Event) {
+		started <- struct{}{}
+		<-release
+		mu.Lock()
+		oldest = append(oldest, e.Args[0])
+		mu.Unlock()
+	}, WithDelivery(DeliveryDropOldest, 2), WithName("oldest"))
+
+	// both subscribers are busy with the first event, while the rest of the
+	// events are queued
+	ops := []*Operation{m.Emit("burst", 0)}
+	<-started
+	<-started
+
+	for i := 1; i < 5; i++ {
+		ops = append(ops, m.Emit("burst", i))
+	}
+
+	close(release)
+
+	for _, op := range ops {
+		_ = op.Wait(context.Background())
+	}
+
+	mu.Lock()
+	if len(newest) != 3 || newest[0] != 0 || newest[2] != 2 {
+		t.Errorf("expected the async subscriber to drop the newest events, got %v", newest)
+	}
+
+	if len(oldest) != 3 || oldest[0] != 0 || oldest[2] != 4 {
+		t.Errorf("expected the drop-oldest subscriber to drop the oldest events, got %v", oldest)
+	}
+	mu.Unlock()
+
+	for _, stats := range m.EventQueueStats() {
+		if stats.Subscriber == "newest" || stats.Subscriber == "oldest" {
+			if stats.Dropped != 2 || stats.Delivered != 3 || stats.Capacity != 2 {
+				t.Errorf("unexpected queue stats: %+v", stats)
+			}
+		}
+	}
+}
+
+func TestDeliveryBlock(t *testing.T) {
+	m := New()
+
+	release := make(chan struct{})
+
+	var mu sync.Mutex
+	var handled []any
+
+	SubscribePattern(m, "work", func(e Event) {
+		<-release
+		mu.Lock()
+		handled = append(handled, e.Args[0])
+		mu.Unlock()
+	}, WithDelivery(DeliveryBlock, 1))
+
+	published := make(chan struct{})
+
+	go func() {
+		for i := 0; i < 3; i++ {
+			m.Emit("work", i)
+		}
+		close(published)
+	}()
+
+	select {
+	case <-published:
+		t.Fatal("expected the publisher to block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-published
+
+	_ = m.Emit("work", 3).Wait(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(handled) != 4 {
+		t.Errorf("expected no events to be dropped, got %v", handled)
+	}
+}
+
+func TestShutdownDrainsEvents(t *testing.T) {
+	m := New()
+	m.SetShutdownTimeout(20 * time.Millisecond)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	SubscribePattern(m, "stuck", func(e Event) {
+		<-release
+	})
+
+	m.Emit("stuck")
+
+	err := m.Shutdown().Wait(context.Background())
+	if !errors.Is(err, ErrShutdownTimeout) {
+		t.Errorf("expected shutdown to time out with undelivered events, got %v", err)
+	}
+}
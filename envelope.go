@@ -0,0 +1,158 @@
+package servicemesh
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Envelope carries the metadata of an event passing through the event bus of
+// the mesh.
+//
+// Every event emitted by the mesh is delivered in an envelope. Subscribers
+// which are interested in the metadata can use SubscribeEnvelope, and
+// listeners bound directly to the raw event emitter receive the envelope as
+// the last argument of every event emitted by the mesh.
+type Envelope struct {
+	// Sequence is a monotonically increasing number, assigned by the mesh
+	// the event was emitted on when the event is published, so it is not yet
+	// set for middleware on the publishing path.
+	Sequence uint64 `json:"sequence"`
+
+	// Time is when the event was emitted.
+	Time time.Time `json:"time"`
+
+	// Source is the name of the service which the event originates from.
+	// For the built-in events concerning a particular service, this is the
+	// service in question, otherwise it is the mesh itself.
+	Source string `json:"source,omitempty"`
+
+	// SourceID is the ID the mesh assigned to the source service.
+	SourceID string `json:"sourceId,omitempty"`
+
+	// Mesh is the name of the mesh the event was emitted on.
+	Mesh string `json:"mesh,omitempty"`
+
+	// CorrelationID is an optional identifier, which can be used to relate
+	// several events to each other.
+	CorrelationID string `json:"correlationId,omitempty"`
+
+	// Origin is the ID of the mesh the event was first emitted on. It only
+	// differs from the ID of the mesh emitting the event for events which
+	// bubbled up from a child mesh, or were received over an EventBridge.
+	Origin string `json:"origin,omitempty"`
+}
+
+// Emit emits an event with the given name and arguments on the event bus of
+// the mesh. If the last argument is an Envelope, it is not passed to the
+// subscribers as an argument, instead its Source, SourceID, CorrelationID,
+// and Origin are used for the envelope of the event.
+//
+// The returned Operation completes once every subscriber has handled the
+// event.
+func (m *mesh) Emit(event string, args ...any) *Operation {
+	args, env := m.userEnvelope(args)
+
+	return operationFromWaitGroup(m.dispatch(event, args, env))
+}
+
+// userEnvelope yields the envelope for a user event. A trailing Envelope
+// argument is removed from the arguments and used instead of the default.
+func (m *mesh) userEnvelope(args []any) ([]any, Envelope) {
+	env := Envelope{Source: m.name, SourceID: m.ServiceID(m)}
+
+	if n := len(args); n > 0 {
+		if meta, ok := args[n-1].(Envelope); ok {
+			return args[:n-1], meta
+		}
+	}
+
+	return args, env
+}
+
+// ServiceID returns the ID which the mesh assigned to a service when it was
+// added, or an empty string if the service is unknown to the mesh.
+func (m *mesh) ServiceID(service Service) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.ids[service]
+}
+
+// assignID gives a service a unique ID, unless it already has one.
+func (m *mesh) assignID(service Service) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ids == nil {
+		m.ids = make(map[Service]string)
+	}
+
+	if _, found := m.ids[service]; !found {
+		m.ids[service] = newID()
+	}
+}
+
+// envelopeFor yields the envelope for a built-in event of the mesh. The
+// source of the event is the service it concerns, if any.
+func (m *mesh) envelopeFor(args []any) Envelope {
+	source := Service(m)
+
+	if len(args) > 0 {
+		if service, ok := args[0].(Service); ok {
+			source = service
+		}
+	}
+
+	return Envelope{Source: source.Name(), SourceID: m.ServiceID(source)}
+}
+
+// dispatch completes the envelope of an event and publishes the event on the
+// event bus of the mesh.
+func (m *mesh) dispatch(event string, args []any, env Envelope) *sync.WaitGroup {
+	m.stamp(&env)
+
+	var pending []*sync.WaitGroup
+
+	publish := func(e Event) {
+		pending = append(pending, m.publish(&e))
+
+		// raw listeners receive the envelope as the last argument
+		raw := append(e.Args[:len(e.Args):len(e.Args)], e.Envelope)
+		pending = append(pending, m.rawEvents().Emit(e.Name, raw...))
+
+		if m.parent != nil && m.bubbleEvents {
+			pending = append(pending, m.parent.dispatch(e.Name, e.Args, e.Envelope))
+		}
+	}
+
+	// the middleware may alter or drop the event before it is published
+	m.withMiddleware(publish)(Event{Name: event, Args: args, Envelope: env})
+
+	return joinWaitGroups(pending...)
+}
+
+// stamp completes the envelope of an event published by this mesh. The
+// sequence number is assigned once the event is published, see publish.
+func (m *mesh) stamp(env *Envelope) {
+	if env.Time.IsZero() {
+		env.Time = time.Now()
+	}
+
+	if env.Mesh == "" {
+		env.Mesh = m.name
+	}
+
+	if env.Origin == "" {
+		env.Origin = m.ServiceID(m)
+	}
+}
+
+// newID generates a random identifier.
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,75 @@
+package servicemesh
+
+import (
+	"errors"
+)
+
+var (
+	// ErrUnresolvedDependencies is reported for a service whose dependencies
+	// could not be resolved from the services present in the mesh.
+	ErrUnresolvedDependencies = errors.New("unresolved dependencies")
+
+	// ErrServiceNotFound is reported when an operation targets a service
+	// which is not present in the mesh.
+	ErrServiceNotFound = errors.New("service not found")
+
+	// ErrUnsupportedMesh is reported when a package-level function is given a
+	// Mesh implementation other than the one provided by this package.
+	ErrUnsupportedMesh = errors.New("unsupported mesh implementation")
+
+	// ErrReplicaSetExists is reported when adding a replica set with a name
+	// which is already in use.
+	ErrReplicaSetExists = errors.New("replica set already exists")
+
+	// ErrReplicaSetNotFound is reported when scaling a replica set which
+	// does not exist.
+	ErrReplicaSetNotFound = errors.New("replica set not found")
+
+	// ErrInvalidReplicaCount is reported when a replica set is scaled to a
+	// negative number of replicas.
+	ErrInvalidReplicaCount = errors.New("invalid replica count")
+
+	// ErrResponderExists is reported when registering a request handler for
+	// a topic which already has a handler.
+	ErrResponderExists = errors.New("request handler already registered")
+
+	// ErrNoResponder is reported when making a request for a topic which has
+	// no handler.
+	ErrNoResponder = errors.New("no request handler registered")
+
+	// ErrRequestNotDelivered is reported when a request was not handed to
+	// its handler, because the event middleware dropped it or the queue of
+	// the handler was full.
+	ErrRequestNotDelivered = errors.New("request not delivered")
+
+	// ErrInvalidPayload is reported when the payload of a request is not of
+	// the type its handler expects.
+	ErrInvalidPayload = errors.New("invalid request payload")
+
+	// ErrHandlerPanicked is reported when a handler panicked while handling
+	// a request or an event.
+	ErrHandlerPanicked = errors.New("handler panicked")
+
+	// ErrInitPanicked is reported for a service whose Init method panicked.
+	ErrInitPanicked = errors.New("service init panicked")
+
+	// ErrLazyInitTimeout is reported when looking up a lazy service whose
+	// deferred initialization did not finish in time.
+	ErrLazyInitTimeout = errors.New("lazy service initialization timed out")
+
+	// ErrShutdownPanicked is reported for a service whose OnShutdown method
+	// panicked.
+	ErrShutdownPanicked = errors.New("service shutdown panicked")
+
+	// ErrShutdownTimeout is reported when the events queued on the event bus
+	// were not delivered within the shutdown timeout of the mesh.
+	ErrShutdownTimeout = errors.New("shutdown timed out")
+
+	// ErrRemoteUnavailable is reported when calling a remote service which
+	// is not connected.
+	ErrRemoteUnavailable = errors.New("remote service unavailable")
+
+	// ErrRemoteCall is reported when a call to a remote service failed on
+	// the remote side.
+	ErrRemoteCall = errors.New("remote call failed")
+)
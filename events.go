@@ -7,6 +7,22 @@ const (
 	EventServiceEventsBound = "service events bound"
 	EventServiceLoggerBound = "service logger bound"
 
+	EventServiceInitDeferred        = "service init deferred"
+	EventServiceDeferredInitStarted = "service deferred init started"
+
+	EventGroupStarted = "group started"
+	EventGroupStopped = "group stopped"
+	EventGroupRemoved = "group removed"
+
+	EventReplicasScaled = "replicas scaled"
+
+	EventHandlerFailed = "event handler failed"
+
+	EventRemoteServiceAdded   = "remote service added"
+	EventRemoteServiceRemoved = "remote service removed"
+
+	EventLogLevelChange = "log level change"
+
 	EventServiceMeshRunLoopInitiated  = "run-loop initiated"
 	EventServiceMeshShutdownInitiated = "shutdown initiated"
 
@@ -0,0 +1,357 @@
+package servicemesh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// JSON-RPC 2.0 error codes used by the ServiceExporter.
+const (
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcCallFailed     = -32000
+
+	// rpcDisconnected is never sent, it fails the pending calls of a
+	// RemoteService which lost its connection
+	rpcDisconnected = -32001
+)
+
+// rpcRequest is a JSON-RPC 2.0 request, whose method is the name of an
+// exported service and the name of one of its methods, joined by a dot.
+type rpcRequest struct {
+	Version string            `json:"jsonrpc"`
+	ID      uint64            `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response.
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// lifecycleMethods are the methods through which the mesh manages and
+// notifies services. A ServiceExporter never lets them be called, so that a
+// caller cannot shut down, rewire or re-initialize an exported service.
+var lifecycleMethods = methodNames(
+	(*Service)(nil),
+	(*HasDependencies)(nil),
+	(*HasLogger)(nil),
+	(*HasLogAttributes)(nil),
+	(*HasGroups)(nil),
+	(*HasGracefulShutdown)(nil),
+	(*HasEventHandlers)(nil),
+	(*ReplaysEventHistory)(nil),
+	(*EventHandlerServiceAdded)(nil),
+	(*EventHandlerServiceRemoved)(nil),
+	(*EventHandlerServiceInitialized)(nil),
+	(*EventHandlerServiceEventsBound)(nil),
+	(*EventHandlerServiceLoggerBound)(nil),
+	(*EventHandlerServiceInitDeferred)(nil),
+	(*EventHandlerServiceDeferredInitStarted)(nil),
+	(*EventHandlerGroupStarted)(nil),
+	(*EventHandlerGroupStopped)(nil),
+	(*EventHandlerGroupRemoved)(nil),
+	(*EventHandlerReplicasScaled)(nil),
+	(*EventHandlerRemoteServiceAdded)(nil),
+	(*EventHandlerRemoteServiceRemoved)(nil),
+	(*EventHandlerLogLevelChange)(nil),
+	(*EventHandlerHandlerFailed)(nil),
+	(*EventHandlerServiceMeshRunLoopInitiated)(nil),
+	(*EventHandlerServiceMeshShutdownInitiated)(nil),
+	(*EventHandlerDependencyResolutionStarted)(nil),
+	(*EventHandlerDependencyResolutionEnded)(nil),
+)
+
+// methodNames yields the names of the methods of interfaces, given as nil
+// pointers to the interfaces.
+func methodNames(interfaces ...any) map[string]bool {
+	names := make(map[string]bool)
+
+	for _, ptr := range interfaces {
+		typ := reflect.TypeOf(ptr).Elem()
+
+		for i := 0; i < typ.NumMethod(); i++ {
+			names[typ.Method(i).Name] = true
+		}
+	}
+
+	return names
+}
+
+// ServiceExporter is a service which makes the methods of services callable
+// from other processes, over unix domain sockets or TCP. Calls are made with
+// JSON-RPC 2.0, where the method of a request is the name of the service and
+// the name of the method, joined by a dot (eg. "Billing.Charge"). In the
+// other process, a RemoteService makes the calls.
+//
+// Any exported method of an exported service can be called, except for the
+// methods through which the mesh manages services, such as Init, OnShutdown,
+// SetLogger, ResolveDependencies and the event handler methods. If the first
+// parameter of a method is a context.Context, it is given a context which is
+// cancelled when the caller disconnects, and is not expected as a parameter
+// of the call. If the last result of a method is an error, it is returned to
+// the caller as the error of the call. The other results are returned as the
+// result of the call: a single result as is, and several results as an
+// array.
+type ServiceExporter struct {
+	mu       sync.Mutex
+	links    netLinks
+	logger   *slog.Logger
+	services map[string]Service
+}
+
+// NewServiceExporter creates a service exporter. Configure where it listens
+// with Listen, and which services it exports with Export, then add it to a
+// mesh.
+func NewServiceExporter() *ServiceExporter {
+	return &ServiceExporter{
+		links:    newNetLinks(),
+		services: make(map[string]Service),
+	}
+}
+
+// Listen makes the exporter accept calls on the given network ("unix" or
+// "tcp") and address. It must be called before the exporter is added to a
+// mesh.
+func (x *ServiceExporter) Listen(network, address string) {
+	x.links.listen = append(x.links.listen, netAddress{network, address})
+}
+
+// Export makes the methods of the services callable by name. Services can
+// be exported at any time.
+func (x *ServiceExporter) Export(services ...Service) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for _, service := range services {
+		x.services[service.Name()] = service
+	}
+}
+
+// Init starts listening for calls.
+func (x *ServiceExporter) Init(_ Mesh) {
+	x.links.start(x.logger, x.serve)
+}
+
+// Name returns the name of the service.
+func (x *ServiceExporter) Name() string {
+	return "Service Exporter"
+}
+
+// SetLogger sets the logger of the service.
+func (x *ServiceExporter) SetLogger(l *slog.Logger) {
+	x.logger = l
+}
+
+// Logger yields the logger of the service.
+func (x *ServiceExporter) Logger() *slog.Logger {
+	return x.logger
+}
+
+// OnShutdown stops accepting calls, and closes every connection.
+func (x *ServiceExporter) OnShutdown() {
+	x.links.stop()
+}
+
+// serve handles the calls made over a connection, until it is closed.
+func (x *ServiceExporter) serve(conn net.Conn) {
+	// calls in progress are cancelled once the caller disconnects
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var calls sync.WaitGroup
+
+	defer func() {
+		cancel()
+		calls.Wait()
+	}()
+
+	var writeMu sync.Mutex
+
+	encoder := json.NewEncoder(conn)
+	decoder := json.NewDecoder(conn)
+
+	for {
+		var req rpcRequest
+
+		if err := decoder.Decode(&req); err != nil {
+			return
+		}
+
+		calls.Add(1)
+
+		go func() {
+			defer calls.Done()
+
+			resp := x.call(ctx, req)
+
+			writeMu.Lock()
+			defer writeMu.Unlock()
+
+			if err := encoder.Encode(resp); err != nil {
+				x.logger.Debug("writing response", "method", req.Method, "error", err)
+			}
+		}()
+	}
+}
+
+// call invokes the method named by a request.
+func (x *ServiceExporter) call(ctx context.Context, req rpcRequest) (resp rpcResponse) {
+	resp = rpcResponse{Version: "2.0", ID: req.ID}
+
+	fail := func(code int, err error) rpcResponse {
+		resp.Error = &rpcError{Code: code, Message: err.Error()}
+		return resp
+	}
+
+	dot := strings.LastIndex(req.Method, ".")
+	if dot < 0 {
+		return fail(rpcInvalidRequest, fmt.Errorf("malformed method %q", req.Method))
+	}
+
+	x.mu.Lock()
+	service, found := x.services[req.Method[:dot]]
+	x.mu.Unlock()
+
+	if !found {
+		return fail(rpcMethodNotFound, fmt.Errorf("%w: %s", ErrServiceNotFound, req.Method[:dot]))
+	}
+
+	name := req.Method[dot+1:]
+
+	method := reflect.ValueOf(service).MethodByName(name)
+	if !method.IsValid() || lifecycleMethods[name] {
+		return fail(rpcMethodNotFound, fmt.Errorf("method not found: %s", req.Method))
+	}
+
+	args, err := rpcArguments(ctx, method.Type(), req.Params)
+	if err != nil {
+		return fail(rpcInvalidParams, err)
+	}
+
+	results, err := invoke(method, args)
+	if err != nil {
+		return fail(rpcCallFailed, err)
+	}
+
+	if resp.Result, err = json.Marshal(results); err != nil {
+		return fail(rpcCallFailed, err)
+	}
+
+	return resp
+}
+
+// rpcArguments decodes the parameters of a call into the arguments of a
+// method.
+func rpcArguments(ctx context.Context, typ reflect.Type, params []json.RawMessage) ([]reflect.Value, error) {
+	var args []reflect.Value
+
+	first := 0
+	if typ.NumIn() > 0 && typ.In(0) == contextType {
+		args = append(args, reflect.ValueOf(ctx))
+		first = 1
+	}
+
+	want := typ.NumIn() - first
+
+	switch {
+	case typ.IsVariadic() && len(params) < want-1:
+		return nil, fmt.Errorf("expected at least %d parameters, got %d", want-1, len(params))
+	case !typ.IsVariadic() && len(params) != want:
+		return nil, fmt.Errorf("expected %d parameters, got %d", want, len(params))
+	}
+
+	for i, raw := range params {
+		in := first + i
+
+		var paramType reflect.Type
+
+		if typ.IsVariadic() && in >= typ.NumIn()-1 {
+			paramType = typ.In(typ.NumIn() - 1).Elem()
+		} else {
+			paramType = typ.In(in)
+		}
+
+		arg := reflect.New(paramType)
+		if err := json.Unmarshal(raw, arg.Interface()); err != nil {
+			return nil, fmt.Errorf("parameter %d: %w", i, err)
+		}
+
+		args = append(args, arg.Elem())
+	}
+
+	return args, nil
+}
+
+// invoke calls a method, and yields its results. A trailing error result is
+// returned as the error, and a panic is reported as ErrHandlerPanicked.
+func invoke(method reflect.Value, args []reflect.Value) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrHandlerPanicked, r)
+		}
+	}()
+
+	out := method.Call(args)
+
+	if n := len(out); n > 0 && method.Type().Out(n-1) == errorType {
+		if failed := out[n-1].Interface(); failed != nil {
+			return nil, failed.(error)
+		}
+
+		out = out[:n-1]
+	}
+
+	switch len(out) {
+	case 0:
+		return nil, nil
+	case 1:
+		return out[0].Interface(), nil
+	}
+
+	list := make([]any, 0, len(out))
+	for _, value := range out {
+		list = append(list, value.Interface())
+	}
+
+	return list, nil
+}
+
+// RemoteCallError is returned by RemoteService.Call when the called method
+// returned an error, or the call could not be made.
+type RemoteCallError struct {
+	// Code is the JSON-RPC error code of the call.
+	Code int
+
+	// Message is the message of the error.
+	Message string
+}
+
+func (e *RemoteCallError) Error() string {
+	return e.Message
+}
+
+// Is makes a RemoteCallError match ErrRemoteCall.
+func (e *RemoteCallError) Is(target error) bool {
+	return target == ErrRemoteCall
+}
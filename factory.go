@@ -0,0 +1,378 @@
+package servicemesh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Scope determines the lifetime of the instances created by a factory which
+// was registered with AddFactory.
+type Scope int
+
+const (
+	// ScopeSingleton factories create a single instance, the first time it
+	// is looked up. The instance is shared by the mesh and all of its
+	// children.
+	ScopeSingleton Scope = iota
+
+	// ScopeTransient factories create a new instance for every lookup.
+	ScopeTransient
+
+	// ScopeScoped factories create one instance for each mesh the instance
+	// is looked up from, so every child mesh gets its own instance.
+	ScopeScoped
+)
+
+// String returns the name of the scope.
+func (s Scope) String() string {
+	switch s {
+	case ScopeSingleton:
+		return "singleton"
+	case ScopeTransient:
+		return "transient"
+	case ScopeScoped:
+		return "scoped"
+	}
+
+	return fmt.Sprintf("Scope(%d)", int(s))
+}
+
+// factory is a registered constructor for instances of a type.
+type factory struct {
+	typ   reflect.Type
+	scope Scope
+	build func(Mesh) (any, error)
+
+	mu        sync.Mutex
+	singleton *factoryInstance
+}
+
+// factoryInstance is an instance created by a factory, which is ready once
+// it has been initialized.
+type factoryInstance struct {
+	instance any
+	ready    *Operation
+}
+
+// AddFactory registers a factory for instances of type T with the mesh.
+//
+// When an instance of T is looked up with Get or Resolve, and no service of
+// the mesh satisfies the lookup, the factory is used to create an instance
+// according to the given scope. Factories registered with a mesh are also
+// used for lookups from its child meshes.
+//
+// Instances which are services are added to the mesh (singleton and scoped
+// instances), or initialized without being added (transient instances), so
+// their loggers, dependencies, and Init method are handled as usual.
+// Every instance is shut down along with the mesh it was looked up from. The
+// mesh only keeps a reference to transient instances which have an
+// OnShutdown method, until it is shut down.
+func AddFactory[T any](m Mesh, fn func(Mesh) (T, error), scope Scope) error {
+	impl, ok := m.(*mesh)
+	if !ok {
+		return ErrUnsupportedMesh
+	}
+
+	impl.Init(nil) // always ensure service mesh is init
+
+	f := &factory{
+		typ:   reflect.TypeOf((*T)(nil)).Elem(),
+		scope: scope,
+		build: func(m Mesh) (any, error) {
+			return fn(m)
+		},
+	}
+
+	impl.mu.Lock()
+	impl.factories = append(impl.factories, f)
+	impl.mu.Unlock()
+
+	impl.logger.Debug("factory added", "type", f.typ.String(), "scope", scope.String())
+
+	return nil
+}
+
+// Resolve finds or creates an instance of type T. Services of the mesh and
+// of its ancestors are considered first, skipping those which report
+// themselves unhealthy through HasHealth, after which the registered
+// factories are used. If no instance can be found or created, an error
+// wrapping ErrServiceNotFound is returned.
+func Resolve[T any](m Mesh) (T, error) {
+	var zero T
+
+	impl, ok := m.(*mesh)
+	if !ok {
+		if candidate, found := Get[T](m); found {
+			return candidate, nil
+		}
+
+		return zero, ErrServiceNotFound
+	}
+
+	instance, err := impl.resolveType(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return zero, err
+	}
+
+	candidate, _ := instance.(T)
+
+	return candidate, nil
+}
+
+// resolveType finds or creates an instance which is assignable to the given
+// type.
+func (m *mesh) resolveType(typ reflect.Type) (any, error) {
+	for owner := m; owner != nil; owner = owner.parent {
+		for _, service := range owner.Services() {
+			if !reflect.TypeOf(service).AssignableTo(typ) || !isHealthy(service) {
+				continue
+			}
+
+			// scoped instances of an ancestor are not shared with children
+			if owner != m && owner.isScopedInstance(service) {
+				continue
+			}
+
+			// typed lookups are load-balanced across the replicas of a set
+			if replica := owner.nextReplica(service, typ); replica != nil {
+				return replica, nil
+			}
+
+			if err := owner.ensureInitialized(service); err != nil {
+				return nil, err
+			}
+
+			return service, nil
+		}
+	}
+
+	for owner := m; owner != nil; owner = owner.parent {
+		if f := owner.findFactory(typ); f != nil {
+			return m.instantiate(owner, f)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrServiceNotFound, typ.String())
+}
+
+func (m *mesh) findFactory(typ reflect.Type) *factory {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, f := range m.factories {
+		if f.typ.AssignableTo(typ) {
+			return f
+		}
+	}
+
+	return nil
+}
+
+func (m *mesh) isScopedInstance(service Service) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, created := range m.scoped {
+		if created.instance == service {
+			return true
+		}
+	}
+
+	return false
+}
+
+// instantiate yields an instance from a factory which is registered with the
+// owner mesh, for a lookup from this mesh.
+//
+// The factory lock serializes the creation of singleton and scoped instances,
+// so two concurrent lookups do not both create an instance. It is released
+// before waiting for the instance to be initialized, so the instance may look
+// up instances of its own factory while it is initialized.
+func (m *mesh) instantiate(owner *mesh, f *factory) (any, error) {
+	var created *factoryInstance
+
+	switch f.scope {
+	case ScopeSingleton:
+		f.mu.Lock()
+
+		if f.singleton == nil {
+			instance, err := owner.build(f)
+			if err != nil {
+				f.mu.Unlock()
+				return nil, err
+			}
+
+			f.singleton = instance
+			owner.add(instance)
+		}
+
+		created = f.singleton
+		f.mu.Unlock()
+	case ScopeScoped:
+		f.mu.Lock()
+
+		m.mu.RLock()
+		instance, found := m.scoped[f]
+		m.mu.RUnlock()
+
+		if !found {
+			var err error
+
+			if instance, err = m.build(f); err != nil {
+				f.mu.Unlock()
+				return nil, err
+			}
+
+			// the instance is known to be scoped before it is initialized,
+			// so that lookups from its Init method do not share it with
+			// child meshes
+			m.mu.Lock()
+			if m.scoped == nil {
+				m.scoped = make(map[*factory]*factoryInstance)
+			}
+			m.scoped[f] = instance
+			m.mu.Unlock()
+
+			m.add(instance)
+		}
+
+		created = instance
+		f.mu.Unlock()
+	default:
+		return m.buildDetached(f)
+	}
+
+	if err := created.ready.Wait(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return created.instance, nil
+}
+
+// build creates an instance with a factory.
+func (m *mesh) build(f *factory) (*factoryInstance, error) {
+	instance, err := f.build(m)
+	if err != nil {
+		return nil, fmt.Errorf("factory for %s: %w", f.typ.String(), err)
+	}
+
+	return &factoryInstance{instance: instance}, nil
+}
+
+// add adds an instance created by a factory to this mesh if it is a service,
+// without waiting for it to be initialized. Other instances are ready right
+// away.
+func (m *mesh) add(created *factoryInstance) {
+	service, ok := created.instance.(Service)
+	if !ok {
+		m.trackDetached(created.instance)
+		created.ready = completedOperation(nil)
+
+		return
+	}
+
+	created.ready = m.Add(service)
+}
+
+// buildDetached creates an instance with a factory without adding it to the
+// mesh. Services are still given a logger, have their dependencies resolved,
+// and are initialized, but they are not services of the mesh: they emit no
+// lifecycle events, and are only remembered to be shut down with the mesh.
+func (m *mesh) buildDetached(f *factory) (any, error) {
+	instance, err := f.build(m)
+	if err != nil {
+		return nil, fmt.Errorf("factory for %s: %w", f.typ.String(), err)
+	}
+
+	service, ok := instance.(Service)
+	if !ok {
+		m.trackDetached(instance)
+		return instance, nil
+	}
+
+	if candidate, ok := service.(HasLogger); ok {
+		candidate.SetLogger(m.newDetachedLogger(service))
+	}
+
+	if resolver, ok := service.(HasDependencies); ok {
+		m.awaitDependencies(resolver)
+	}
+
+	if err = m.initDetached(service); err != nil {
+		return nil, err
+	}
+
+	m.trackDetached(instance)
+
+	return instance, nil
+}
+
+// initDetached initializes a service which is not part of the mesh,
+// recovering from a panic within its Init method.
+func (m *mesh) initDetached(service Service) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Error("service init panicked", "service", service.Name(), "panic", r)
+			err = fmt.Errorf("%w: %s: %v", ErrInitPanicked, service.Name(), r)
+		}
+	}()
+
+	service.Init(m)
+
+	return nil
+}
+
+// trackDetached remembers a factory instance which is not a service of the
+// mesh, so that it can be shut down along with the mesh.
+func (m *mesh) trackDetached(instance any) {
+	if _, ok := instance.(interface{ OnShutdown() }); !ok {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.detached = append(m.detached, instance)
+}
+
+// shutdownDetached shuts down every factory instance which is not a service
+// of the mesh.
+func (m *mesh) shutdownDetached() error {
+	m.mu.Lock()
+	list := m.detached
+	m.detached = nil
+	m.mu.Unlock()
+
+	var errs []error
+
+	for _, instance := range list {
+		if err := m.shutdownDetachedInstance(instance.(interface{ OnShutdown() })); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// shutdownDetachedInstance shuts down a factory instance which is not a
+// service of the mesh, recovering from a panic within its OnShutdown method.
+func (m *mesh) shutdownDetachedInstance(instance interface{ OnShutdown() }) (err error) {
+	name := fmt.Sprintf("%T", instance)
+	if service, ok := instance.(Service); ok {
+		name = service.Name()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Error("service shutdown panicked", "service", name, "panic", r)
+			err = fmt.Errorf("%w: %s: %v", ErrShutdownPanicked, name, r)
+		}
+	}()
+
+	instance.OnShutdown()
+
+	return nil
+}
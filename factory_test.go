@@ -0,0 +1,140 @@
+package servicemesh
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFactoryTransient(t *testing.T) {
+	m := New()
+
+	_ = AddFactory[*scopedService](m, func(Mesh) (*scopedService, error) {
+		return &scopedService{scope: ScopeTransient}, nil
+	}, ScopeTransient)
+
+	a, errA := Resolve[*scopedService](m)
+	b, errB := Resolve[*scopedService](m)
+
+	if errA != nil || errB != nil {
+		t.Fatalf("unexpected errors: %v, %v", errA, errB)
+	}
+
+	if a == b {
+		t.Error("transient factories should create a new instance for every lookup")
+	}
+
+	if !a.initialized || containsService(m.Services(), a) || m.(*mesh).isReady(a) {
+		t.Error("transient instances should be initialized without being added")
+	}
+
+	_ = m.Shutdown().Wait(context.Background())
+
+	if !a.stopped || !b.stopped {
+		t.Error("transient instances should be shut down with the mesh")
+	}
+
+	m.(*mesh).logContexts.mu.Lock()
+	_, found := m.(*mesh).logContexts.services[a]
+	m.(*mesh).logContexts.mu.Unlock()
+
+	if found {
+		t.Error("transient instances should not be retained by the mesh")
+	}
+}
+
+func TestFactorySingletonAndScoped(t *testing.T) {
+	m := New()
+
+	_ = AddFactory[*scopedService](m, func(Mesh) (*scopedService, error) {
+		return &scopedService{scope: ScopeSingleton}, nil
+	}, ScopeSingleton)
+
+	_ = AddFactory[*countingService](m, func(Mesh) (*countingService, error) {
+		return &countingService{}, nil
+	}, ScopeScoped)
+
+	child := m.NewChild("child")
+
+	a, _ := Get[*scopedService](m)
+	b, _ := Get[*scopedService](child)
+
+	if a == nil || a != b {
+		t.Error("singleton factories should share one instance with child meshes")
+	}
+
+	if !containsService(m.Services(), a) {
+		t.Error("singleton instances should be added to the mesh")
+	}
+
+	parentScoped, _ := Get[*countingService](m)
+	childScoped, _ := Get[*countingService](child)
+	again, _ := Get[*countingService](child)
+
+	if parentScoped == nil || childScoped == nil {
+		t.Fatal("expected scoped instances")
+	}
+
+	if !containsService(child.Services(), childScoped) || containsService(m.Services(), childScoped) {
+		t.Error("scoped instances should be added to the mesh they were looked up from")
+	}
+
+	if childScoped != again {
+		t.Error("scoped factories should reuse the instance of a mesh")
+	}
+}
+
+type scopedService struct {
+	scope       Scope
+	initialized bool
+	stopped     bool
+}
+
+func (s *scopedService) Init(_ Mesh) { s.initialized = true }
+
+func (s *scopedService) Name() string { return "scoped " + s.scope.String() }
+
+func (s *scopedService) OnShutdown() { s.stopped = true }
+
+// reentrantService looks up an instance of its own factory, from a child
+// mesh of its own, while it is initialized.
+type reentrantService struct {
+	nested atomic.Pointer[reentrantService]
+}
+
+func (s *reentrantService) Name() string { return "reentrant" }
+
+func (s *reentrantService) Init(m Mesh) {
+	if m.(*mesh).parent != nil {
+		return
+	}
+
+	if nested, err := Resolve[*reentrantService](m.NewChild("nested")); err == nil {
+		s.nested.Store(nested)
+	}
+}
+
+func TestFactoryReentrantLookup(t *testing.T) {
+	m := New()
+
+	_ = AddFactory[*reentrantService](m, func(Mesh) (*reentrantService, error) {
+		return &reentrantService{}, nil
+	}, ScopeScoped)
+
+	done := make(chan *reentrantService)
+
+	go func() {
+		instance, _ := Resolve[*reentrantService](m)
+		done <- instance
+	}()
+
+	select {
+	case instance := <-done:
+		if instance == nil || instance.nested.Load() == nil || instance.nested.Load() == instance {
+			t.Error("expected the instance to look up an instance of its own from a child mesh")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("looking up an instance of a factory while it is initialized should not deadlock")
+	}
+}
@@ -0,0 +1,448 @@
+package servicemesh
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHeartbeatInterval = time.Second
+
+	// heartbeatMisses is the number of heartbeats a member of a federation
+	// may miss before its services are removed
+	heartbeatMisses = 3
+)
+
+// Federation is a service which lets meshes in separate processes discover
+// each other's services, over unix domain sockets or TCP. A federation can
+// listen for connections, connect to other federations, or both.
+//
+// Every member of the federation periodically announces the services of its
+// mesh to the others. The services announced by other members are added to
+// the mesh as FederatedService entries, so that they are included in
+// Services() and typed lookups, and EventRemoteServiceAdded and
+// EventRemoteServiceRemoved are emitted as they come and go. The
+// announcements double as heartbeats: when a member misses several in a
+// row, its services are removed.
+//
+// A member relays the announcements it receives to its other connections,
+// so that several processes can share a single listening member as a hub.
+// Announcements are relayed only once, so in other topologies each member
+// must be connected to every other member, directly or through a hub.
+type Federation struct {
+	mu       sync.Mutex
+	links    netLinks
+	mesh     *mesh
+	logger   *slog.Logger
+	interval time.Duration
+	peers    map[*federationPeer]bool
+	members  map[string]*federationMember
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// FederatedService stands in for a service of another mesh, announced
+// through a Federation. It only describes the remote service; to call its
+// methods, see RemoteService.
+type FederatedService struct {
+	mu      sync.Mutex
+	name    string
+	id      string
+	mesh    string
+	healthy bool
+}
+
+// Init is a no-op, a federated service is initialized by its own mesh.
+func (s *FederatedService) Init(_ Mesh) {}
+
+// Name returns the name of the remote service.
+func (s *FederatedService) Name() string {
+	return s.name
+}
+
+// ID returns the ID the remote mesh assigned to the service.
+func (s *FederatedService) ID() string {
+	return s.id
+}
+
+// RemoteMesh returns the name of the mesh the service belongs to.
+func (s *FederatedService) RemoteMesh() string {
+	return s.mesh
+}
+
+// Healthy returns true while the mesh the service belongs to keeps
+// announcing it.
+func (s *FederatedService) Healthy() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.healthy
+}
+
+func (s *FederatedService) setHealthy(healthy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.healthy = healthy
+}
+
+// IsRemote returns true if the service stands in for a service of another
+// mesh, announced through a Federation.
+func IsRemote(service Service) bool {
+	_, ok := service.(*FederatedService)
+	return ok
+}
+
+// announcement is the message exchanged by the members of a federation.
+type announcement struct {
+	MeshID   string             `json:"meshId"`
+	Mesh     string             `json:"mesh"`
+	Services []announcedService `json:"services,omitempty"`
+	Relayed  bool               `json:"relayed,omitempty"`
+	Leaving  bool               `json:"leaving,omitempty"`
+}
+
+type announcedService struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+type federationPeer struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func (p *federationPeer) send(msg announcement) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.encoder.Encode(msg)
+}
+
+// federationMember is another mesh of the federation.
+type federationMember struct {
+	lastSeen time.Time
+	services map[string]*FederatedService
+}
+
+// NewFederation creates a federation service. Configure where it listens and
+// connects to with Listen and Connect, then add it to a mesh.
+func NewFederation() *Federation {
+	return &Federation{
+		links:    newNetLinks(),
+		interval: defaultHeartbeatInterval,
+		peers:    make(map[*federationPeer]bool),
+		members:  make(map[string]*federationMember),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Listen makes the federation accept connections from other members on the
+// given network ("unix" or "tcp") and address. It must be called before the
+// federation is added to a mesh.
+func (f *Federation) Listen(network, address string) {
+	f.links.listen = append(f.links.listen, netAddress{network, address})
+}
+
+// Connect makes the federation connect to the member listening on the given
+// network and address, reconnecting with a backoff whenever the connection
+// is lost. It must be called before the federation is added to a mesh.
+func (f *Federation) Connect(network, address string) {
+	f.links.dial = append(f.links.dial, netAddress{network, address})
+}
+
+// SetHeartbeat sets how often the services of the mesh are announced. The
+// services of a member are removed once it has not been heard from for
+// three intervals. It must be called before the federation is added to a
+// mesh.
+func (f *Federation) SetHeartbeat(interval time.Duration) {
+	f.interval = interval
+}
+
+// Init starts announcing the services of the mesh.
+func (f *Federation) Init(m Mesh) {
+	impl, ok := m.(*mesh)
+	if !ok {
+		f.logger.Error("starting federation", "error", ErrUnsupportedMesh)
+		return
+	}
+
+	f.mesh = impl
+	f.links.start(f.logger, f.serve)
+
+	f.wg.Add(1)
+	go f.heartbeat()
+}
+
+// Name returns the name of the service.
+func (f *Federation) Name() string {
+	return "Federation"
+}
+
+// SetLogger sets the logger of the service.
+func (f *Federation) SetLogger(l *slog.Logger) {
+	f.logger = l
+}
+
+// Logger yields the logger of the service.
+func (f *Federation) Logger() *slog.Logger {
+	return f.logger
+}
+
+// OnShutdown tells the other members that this mesh is leaving, closes every
+// connection, and removes the services of the other members from the mesh.
+func (f *Federation) OnShutdown() {
+	close(f.stop)
+	f.wg.Wait()
+
+	if f.mesh == nil {
+		return
+	}
+
+	leaving := f.announcement()
+	leaving.Services, leaving.Leaving = nil, true
+
+	for _, peer := range f.connected(nil) {
+		_ = peer.send(leaving)
+	}
+
+	f.links.stop()
+
+	f.mu.Lock()
+	ids := make([]string, 0, len(f.members))
+	for id := range f.members {
+		ids = append(ids, id)
+	}
+	f.mu.Unlock()
+
+	for _, id := range ids {
+		f.removeMember(id)
+	}
+}
+
+// heartbeat announces the services of the mesh every interval, and removes
+// the services of members which stopped announcing theirs.
+func (f *Federation) heartbeat() {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+		}
+
+		f.announce(nil)
+
+		deadline := time.Now().Add(-heartbeatMisses * f.interval)
+
+		var lost []string
+
+		f.mu.Lock()
+		for id, member := range f.members {
+			if member.lastSeen.Before(deadline) {
+				lost = append(lost, id)
+			}
+		}
+		f.mu.Unlock()
+
+		for _, id := range lost {
+			f.logger.Warn("federation member stopped responding", "mesh", id)
+			f.removeMember(id)
+		}
+	}
+}
+
+// announcement yields the announcement of the services of the mesh. The
+// services of other members are not announced.
+func (f *Federation) announcement() announcement {
+	msg := announcement{MeshID: f.mesh.ServiceID(f.mesh), Mesh: f.mesh.Name()}
+
+	for _, service := range f.mesh.Services() {
+		if _, isMesh := service.(*mesh); isMesh || IsRemote(service) {
+			continue
+		}
+
+		msg.Services = append(msg.Services, announcedService{
+			Name: service.Name(),
+			ID:   f.mesh.ServiceID(service),
+		})
+	}
+
+	return msg
+}
+
+// announce sends the announcement of the mesh to a single peer, or to every
+// peer if none is given.
+func (f *Federation) announce(to *federationPeer) {
+	msg := f.announcement()
+
+	peers := []*federationPeer{to}
+	if to == nil {
+		peers = f.connected(nil)
+	}
+
+	for _, peer := range peers {
+		if err := peer.send(msg); err != nil {
+			f.logger.Debug("announcing services", "error", err)
+		}
+	}
+}
+
+// connected returns the connected peers, except the given one.
+func (f *Federation) connected(except *federationPeer) []*federationPeer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	list := make([]*federationPeer, 0, len(f.peers))
+
+	for peer := range f.peers {
+		if peer != except {
+			list = append(list, peer)
+		}
+	}
+
+	return list
+}
+
+// serve receives announcements from a connected member, until the connection
+// is closed.
+func (f *Federation) serve(conn net.Conn) {
+	peer := &federationPeer{encoder: json.NewEncoder(conn)}
+
+	f.mu.Lock()
+	f.peers[peer] = true
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		delete(f.peers, peer)
+		f.mu.Unlock()
+	}()
+
+	// a new member learns of our services straight away
+	f.announce(peer)
+
+	decoder := json.NewDecoder(conn)
+
+	for {
+		var msg announcement
+
+		if err := decoder.Decode(&msg); err != nil {
+			return
+		}
+
+		f.receive(peer, msg)
+	}
+}
+
+// receive applies an announcement from another member, and relays it to the
+// other connected members.
+func (f *Federation) receive(from *federationPeer, msg announcement) {
+	if msg.MeshID == "" || msg.MeshID == f.mesh.ServiceID(f.mesh) {
+		return
+	}
+
+	if !msg.Relayed {
+		relayed := msg
+		relayed.Relayed = true
+
+		for _, peer := range f.connected(from) {
+			_ = peer.send(relayed)
+		}
+	}
+
+	if msg.Leaving {
+		f.removeMember(msg.MeshID)
+		return
+	}
+
+	f.mu.Lock()
+
+	member, found := f.members[msg.MeshID]
+	if !found {
+		member = &federationMember{services: make(map[string]*FederatedService)}
+		f.members[msg.MeshID] = member
+	}
+
+	member.lastSeen = time.Now()
+
+	var added, removed []*FederatedService
+
+	announced := make(map[string]bool, len(msg.Services))
+
+	for _, svc := range msg.Services {
+		announced[svc.ID] = true
+
+		if _, known := member.services[svc.ID]; known {
+			continue
+		}
+
+		entry := &FederatedService{
+			name:    svc.Name,
+			id:      svc.ID,
+			mesh:    msg.Mesh,
+			healthy: true,
+		}
+
+		member.services[svc.ID] = entry
+		added = append(added, entry)
+	}
+
+	for id, entry := range member.services {
+		if !announced[id] {
+			delete(member.services, id)
+			removed = append(removed, entry)
+		}
+	}
+
+	f.mu.Unlock()
+
+	for _, entry := range added {
+		f.mesh.Add(entry)
+		f.mesh.emit(EventRemoteServiceAdded, entry)
+	}
+
+	for _, entry := range removed {
+		f.removeService(entry)
+	}
+}
+
+// removeMember removes every service of a member from the mesh.
+func (f *Federation) removeMember(id string) {
+	f.mu.Lock()
+
+	member, found := f.members[id]
+	delete(f.members, id)
+
+	f.mu.Unlock()
+
+	if !found {
+		return
+	}
+
+	for _, entry := range member.services {
+		f.removeService(entry)
+	}
+}
+
+func (f *Federation) removeService(entry *FederatedService) {
+	entry.setHealthy(false)
+	f.mesh.Remove(entry)
+	f.mesh.emit(EventRemoteServiceRemoved, entry)
+}
+
+// remoteMeshOf returns the name of the mesh a federated service belongs to.
+func remoteMeshOf(service Service) string {
+	if entry, ok := service.(*FederatedService); ok {
+		return entry.RemoteMesh()
+	}
+
+	return ""
+}
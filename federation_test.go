@@ -0,0 +1,106 @@
+package servicemesh
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type membershipRecorder struct {
+	mu      sync.Mutex
+	added   []string
+	removed []string
+}
+
+func (r *membershipRecorder) Init(_ Mesh)  {}
+func (r *membershipRecorder) Name() string { return "Membership Recorder" }
+
+func (r *membershipRecorder) OnRemoteServiceAdded(service Service) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.added = append(r.added, service.Name())
+}
+
+func (r *membershipRecorder) OnRemoteServiceRemoved(service Service) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removed = append(r.removed, service.Name())
+}
+
+func (r *membershipRecorder) has(list *[]string, name string) func() bool {
+	return func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		return containsString(*list, name)
+	}
+}
+
+func remoteNamed(m Mesh, name string) *FederatedService {
+	for _, service := range m.Services() {
+		if entry, ok := service.(*FederatedService); ok && entry.Name() == name {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+func TestFederation(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "federation.sock")
+
+	billing := New("billing")
+	calculator := &calculatorService{}
+	_ = billing.Add(calculator).Wait(context.Background())
+
+	billingFederation := NewFederation()
+	billingFederation.Listen("unix", socket)
+	billingFederation.SetHeartbeat(10 * time.Millisecond)
+	_ = billing.Add(billingFederation).Wait(context.Background())
+
+	shop := New("shop")
+	recorder := &membershipRecorder{}
+	_ = shop.Add(recorder).Wait(context.Background())
+
+	shopFederation := NewFederation()
+	shopFederation.Connect("unix", socket)
+	shopFederation.SetHeartbeat(10 * time.Millisecond)
+	_ = shop.Add(shopFederation).Wait(context.Background())
+
+	defer shopFederation.OnShutdown()
+
+	waitFor(t, "the remote service to be announced", recorder.has(&recorder.added, "Calculator"))
+
+	entry := remoteNamed(shop, "Calculator")
+	if entry == nil || !IsRemote(entry) || entry.RemoteMesh() != "billing" || !entry.Healthy() {
+		t.Fatalf("expected the calculator to be a healthy remote service of the billing mesh, got %+v", entry)
+	}
+
+	if entry.ID() != billing.ServiceID(calculator) {
+		t.Errorf("expected the remote service to keep its ID")
+	}
+
+	if remoteNamed(billing, "Membership Recorder") == nil {
+		t.Errorf("expected the announcements to go both ways")
+	}
+
+	// services removed from their mesh are removed from the others
+	_ = billing.Remove(calculator).Wait(context.Background())
+
+	waitFor(t, "the remote service to be removed", recorder.has(&recorder.removed, "Calculator"))
+
+	if remoteNamed(shop, "Calculator") != nil || entry.Healthy() {
+		t.Errorf("expected the removed remote service to be gone")
+	}
+
+	// a member which stops sending heartbeats is removed
+	close(billingFederation.stop)
+	billingFederation.wg.Wait()
+	billingFederation.links.stop()
+
+	waitFor(t, "the unresponsive member to be removed", recorder.has(&recorder.removed, "Federation"))
+}
@@ -0,0 +1,118 @@
+package servicemesh
+
+// AddToGroup tags the given services as members of the named group. The
+// services do not need to have been added to the mesh yet; StartGroup will
+// add any members which are not already present.
+func (m *mesh) AddToGroup(group string, services ...Service) {
+	m.mu.Lock()
+
+	if m.groups == nil {
+		m.groups = make(map[string][]Service)
+	}
+
+	for _, service := range services {
+		if !containsService(m.groups[group], service) {
+			m.groups[group] = append(m.groups[group], service)
+		}
+	}
+
+	m.mu.Unlock()
+
+	// the members may now log at the level of the group
+	m.refreshLogLevels()
+	m.refreshLogGroups()
+}
+
+// ServicesInGroup returns the members of the named group, regardless of
+// whether they are currently running within the mesh.
+func (m *mesh) ServicesInGroup(group string) (list []Service) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append(list, m.groups[group]...)
+}
+
+// StartGroup adds every member of the named group which is not already
+// present in the mesh. The returned Operation completes when all of the
+// members have been initialized.
+func (m *mesh) StartGroup(group string) *Operation {
+	running := m.Services()
+
+	var pending []*Operation
+
+	for _, service := range m.ServicesInGroup(group) {
+		if containsService(running, service) {
+			continue
+		}
+
+		pending = append(pending, m.Add(service))
+	}
+
+	pending = append(pending, operationFromWaitGroup(m.emit(EventGroupStarted, group)))
+
+	return joinOperations(pending...)
+}
+
+// StopGroup gracefully shuts down and removes every member of the named
+// group from the mesh. The members remain in the group, so the group can be
+// started again with StartGroup.
+func (m *mesh) StopGroup(group string) *Operation {
+	running := m.Services()
+
+	var pending []*Operation
+
+	for _, service := range m.ServicesInGroup(group) {
+		if !containsService(running, service) {
+			continue
+		}
+
+		if quitter, ok := service.(HasGracefulShutdown); ok {
+			m.logger.Debug("shutting down service", "service", service.Name(), "group", group)
+			pending = append(pending, completedOperation(m.safeShutdownService(quitter)))
+		}
+
+		pending = append(pending, m.Remove(service))
+	}
+
+	pending = append(pending, operationFromWaitGroup(m.emit(EventGroupStopped, group)))
+
+	return joinOperations(pending...)
+}
+
+// RemoveGroup stops every member of the named group, and then forgets the
+// group entirely.
+func (m *mesh) RemoveGroup(group string) *Operation {
+	stopped := m.StopGroup(group)
+
+	m.mu.Lock()
+	delete(m.groups, group)
+	m.mu.Unlock()
+
+	m.refreshLogLevels()
+	m.refreshLogGroups()
+
+	return joinOperations(stopped, operationFromWaitGroup(m.emit(EventGroupRemoved, group)))
+}
+
+// bindGroups adds a service to the groups it declares by implementing the
+// HasGroups interface.
+func (m *mesh) bindGroups(service Service) {
+	candidate, ok := service.(HasGroups)
+	if !ok {
+		return
+	}
+
+	for _, group := range candidate.Groups() {
+		m.AddToGroup(group, service)
+	}
+}
+
+func containsService(list []Service, service Service) bool {
+	for _, candidate := range list {
+		if candidate == service {
+			return true
+		}
+	}
+
+	return false
+}
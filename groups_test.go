@@ -0,0 +1,54 @@
+package servicemesh
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	m := New()
+
+	a, b := &groupedService{name: "a"}, &groupedService{name: "b"}
+
+	m.Add(a).Wait(context.Background())
+	m.AddToGroup("plugins", b)
+
+	if n := len(m.ServicesInGroup("plugins")); n != 2 {
+		t.Fatalf("expected 2 services in group, got %d", n)
+	}
+
+	m.StartGroup("plugins").Wait(context.Background())
+
+	if !containsService(m.Services(), b) {
+		t.Error("starting a group should add its members")
+	}
+
+	m.StopGroup("plugins").Wait(context.Background())
+
+	if containsService(m.Services(), a) || containsService(m.Services(), b) {
+		t.Error("stopping a group should remove its members")
+	}
+
+	if !a.stopped || !b.stopped {
+		t.Error("stopping a group should shut down its members")
+	}
+
+	m.RemoveGroup("plugins").Wait(context.Background())
+
+	if n := len(m.ServicesInGroup("plugins")); n != 0 {
+		t.Errorf("expected group to be removed, found %d services", n)
+	}
+}
+
+type groupedService struct {
+	name    string
+	stopped bool
+}
+
+func (g *groupedService) Init(_ Mesh) {}
+
+func (g *groupedService) Name() string { return g.name }
+
+func (g *groupedService) Groups() []string { return []string{"plugins"} }
+
+func (g *groupedService) OnShutdown() { g.stopped = true }
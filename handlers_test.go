@@ -0,0 +1,59 @@
+package servicemesh
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCustomEventHandlers(t *testing.T) {
+	m := New()
+	s := &customHandlerService{received: make(chan any, 10)}
+
+	bound := make(chan Service, 10)
+	Subscribe(m, func(e ServiceEventsBound) {
+		bound <- e.Service
+	})
+
+	_ = m.Add(s).Wait(context.Background())
+
+	select {
+	case service := <-bound:
+		if service != s {
+			t.Errorf("unexpected service: %v", service.Name())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected EventServiceEventsBound to be emitted")
+	}
+
+	_ = m.Emit("invoice paid", 42).Wait(context.Background())
+
+	if got := <-s.received; got != 42 {
+		t.Errorf("unexpected argument: %v", got)
+	}
+
+	_ = m.Remove(s).Wait(context.Background())
+	_ = m.Emit("invoice paid", 43).Wait(context.Background())
+
+	select {
+	case got := <-s.received:
+		t.Errorf("handler still bound after removal, received %v", got)
+	default:
+	}
+}
+
+type customHandlerService struct {
+	received chan any
+}
+
+func (c *customHandlerService) Init(_ Mesh) {}
+
+func (c *customHandlerService) Name() string { return "custom handlers" }
+
+func (c *customHandlerService) EventHandlers() map[string]func(args ...any) {
+	return map[string]func(args ...any){
+		"invoice paid": func(args ...any) {
+			c.received <- args[0]
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package servicemesh
+
+const defaultEventHistoryRetention = 256
+
+// SetEventHistoryRetention sets the number of past events which the mesh
+// retains in its event history. The oldest events are discarded once the
+// history is full. A retention of zero disables the event history.
+func (m *mesh) SetEventHistoryRetention(n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	m.bus.mu.Lock()
+	defer m.bus.mu.Unlock()
+
+	m.historyRetention = n
+
+	if excess := len(m.bus.history) - n; excess > 0 {
+		m.bus.history = append([]Event(nil), m.bus.history[excess:]...)
+	}
+}
+
+// EventHistory returns the events retained in the event history of the
+// mesh, oldest first.
+func (m *mesh) EventHistory() (list []Event) {
+	m.bus.mu.RLock()
+	defer m.bus.mu.RUnlock()
+
+	return append(list, m.bus.history...)
+}
+
+// recordHistory appends an event to the event history. The bus must be
+// locked by the caller.
+func (m *mesh) recordHistory(e Event) {
+	if m.historyRetention == 0 {
+		return
+	}
+
+	if len(m.bus.history) >= m.historyRetention {
+		// shift rather than reslice, so the backing array does not grow
+		copy(m.bus.history, m.bus.history[1:])
+		m.bus.history = m.bus.history[:len(m.bus.history)-1]
+	}
+
+	m.bus.history = append(m.bus.history, e)
+}
+
+// historyFor returns the past events which match a subscription, oldest
+// first. The bus must be locked by the caller.
+func (m *mesh) historyFor(sub *subscription) (list []Event) {
+	for _, e := range m.bus.history {
+		if sub.matches(e) {
+			list = append(list, e)
+		}
+	}
+
+	return list
+}
+
+// replayOptions yields the subscription options for the event handler
+// interfaces of a service.
+func replayOptions(service Service) []SubscribeOption {
+	if candidate, ok := service.(ReplaysEventHistory); ok && candidate.ReplayEventHistory() {
+		return []SubscribeOption{WithReplay()}
+	}
+
+	return nil
+}
@@ -1,13 +1,23 @@
 package servicemesh
 
 import (
+	"context"
 	"io"
 	"log/slog"
 	"sync"
-
-	ee "github.com/gravestench/eventemitter"
+	"time"
 )
 
+// EventEmitter is the raw event emitter of a Mesh, see Mesh.Events. It is
+// satisfied by *eventemitter.EventEmitter.
+type EventEmitter interface {
+	Emit(event string, args ...any) *sync.WaitGroup
+	On(event string, fn func(...any))
+	Once(event string, fn func(...any))
+	Off(event string, fn func(...any))
+	RemoveAllListeners(events ...string)
+}
+
 // Mesh is the abstract idea of the service mesh, an interface.
 //
 // The Mesh interface defines the operations that can be performed with
@@ -15,20 +25,125 @@ import (
 // container for services and uses other interfaces like HasDependencies to
 // work with them and do things automatically on their behalf.
 type Mesh interface {
-	// Add a single service to the Mesh.
-	Add(Service) *sync.WaitGroup
+	// Add a single service to the Mesh. The returned Operation completes once
+	// the service has been initialized.
+	Add(Service) *Operation
+
+	// AddAll adds several services to the Mesh, registering all of them
+	// before any dependencies are resolved, and initializing them together.
+	AddAll(services ...Service) *Batch
+
+	// AddLazy adds a service to the Mesh, deferring its initialization until
+	// the service is first looked up.
+	AddLazy(Service) *Operation
+
+	// SetLazyInitTimeout sets how long a lookup waits for the deferred
+	// initialization of a lazy service.
+	SetLazyInitTimeout(d time.Duration)
+
+	// Lookup finds a service by name among the services of the Mesh and its
+	// ancestors, initializing it if it was added with AddLazy. A lazy service
+	// whose initialization fails or does not finish in time is reported as
+	// not found.
+	Lookup(name string) (Service, bool)
+
+	// AddReplicas adds n replicas of a service, created by the factory.
+	AddReplicas(name string, factory ReplicaFactory, n int) *Operation
+
+	// Scale changes the number of replicas of a replica set.
+	Scale(name string, n int) *Operation
+
+	// Replicas returns the current replicas of a replica set.
+	Replicas(name string) []Service
 
 	// Remove a specific service from the Mesh.
-	Remove(Service) *sync.WaitGroup
+	Remove(Service) *Operation
 
 	// Services returns a pointer to a slice of Services currently managed by
 	// the service Mesh **which are ready to be used**.
 	Services() []Service
 
-	Events() *ee.EventEmitter
+	// Events yields the raw event emitter of the Mesh. Listeners bound to it
+	// receive the Envelope as the last argument, and events emitted with it
+	// pass through the event bus just like those emitted with Emit.
+	Events() EventEmitter
+
+	// Emit emits an event on the event bus of the Mesh, delivering it in an
+	// Envelope. A trailing Envelope argument sets the event metadata.
+	Emit(event string, args ...any) *Operation
+
+	// UseEventMiddleware adds middleware to the publishing and delivery path
+	// of every event on the event bus of the Mesh.
+	UseEventMiddleware(middleware ...EventMiddleware)
+
+	// Handle registers the single handler for requests of a topic.
+	Handle(topic string, handler RequestHandler) (Unsubscribe, error)
+
+	// Request sends a request to the handler of a topic and waits for its
+	// reply.
+	Request(ctx context.Context, topic string, payload any) (any, error)
+
+	// ServiceID returns the ID the Mesh assigned to a service.
+	ServiceID(service Service) string
+
+	// SetEventHistoryRetention sets how many past events the Mesh retains.
+	SetEventHistoryRetention(n int)
+
+	// EventHistory returns the retained past events, oldest first.
+	EventHistory() []Event
+
+	// SetEventDelivery sets the default delivery mode and queue size of
+	// subscribers of the event bus.
+	SetEventDelivery(mode DeliveryMode, queueSize int)
+
+	// EventQueueStats returns the queue statistics of every subscriber.
+	EventQueueStats() []QueueStats
+
+	// SetShutdownTimeout sets how long Shutdown waits for queued events.
+	SetShutdownTimeout(d time.Duration)
+
+	// SetDeadLetterSink sets the function receiving events which a
+	// subscriber failed to handle.
+	SetDeadLetterSink(sink func(DeadLetter))
+
+	// DeadLetters returns the most recent events which subscribers failed to
+	// handle, oldest first.
+	DeadLetters() []DeadLetter
+
+	// SetRecentLogRetention sets how many log records the Mesh retains in
+	// memory for every service.
+	SetRecentLogRetention(n int)
+
+	// RecentLogs returns the last n retained log records of a service,
+	// oldest first.
+	RecentLogs(service string, n int) []LogRecord
 
 	Run()
-	Shutdown() *sync.WaitGroup
+	Shutdown() *Operation
+
+	// NewChild creates a child mesh, scoped beneath this mesh. Services of
+	// the child can resolve dependencies from this mesh, and the child is
+	// shut down when this mesh shuts down.
+	NewChild(name string) Mesh
+
+	// AddToGroup tags services as members of a named group.
+	AddToGroup(group string, services ...Service)
+
+	// ServicesInGroup returns the members of a named group.
+	ServicesInGroup(group string) []Service
+
+	// StartGroup adds all members of a group which are not yet in the Mesh.
+	StartGroup(group string) *Operation
+
+	// StopGroup shuts down and removes all members of a group from the Mesh.
+	StopGroup(group string) *Operation
+
+	// RemoveGroup stops all members of a group and forgets the group.
+	RemoveGroup(group string) *Operation
+
+	// SetEventBubbling determines whether the events of a child mesh are
+	// also emitted on the event bus of its parent.
+	SetEventBubbling(enabled bool)
 
 	slogLoggerMethods
 }
@@ -37,6 +152,13 @@ type slogLoggerMethods interface {
 	SetLogHandler(handler slog.Handler)
 	SetLogLevel(level slog.Level)
 	SetLogDestination(dst io.Writer)
+	SetLogFormat(format LogFormat)
+	WrapLogHandler(wrappers ...LogHandlerWrapper)
+	SetLogAttrs(attrs LogAttr)
+	SetServiceLogFiles(dir string, rotation Rotation)
+	SetServiceLogLevel(name string, level slog.Level)
+	SetGroupLogLevel(group string, level slog.Level)
+	SetLogLevels(spec string) error
 }
 
 // Service represents a generic service within a service mesh.
@@ -53,6 +175,20 @@ type Service interface {
 	Name() string
 }
 
+// HasHealth is an optional interface, for services which can report whether
+// they are currently able to do their work, such as a RemoteService which
+// has lost its connection.
+//
+// Unhealthy services are still listed by Services, but they are skipped by
+// Lookup, Get and Resolve, and are not handed to services resolving their
+// dependencies until they are healthy again.
+type HasHealth interface {
+	Service
+
+	// Healthy returns true if the service is able to do its work.
+	Healthy() bool
+}
+
 // HasDependencies represents a service that can resolve its dependencies.
 //
 // The HasDependencies interface extends the Service interface and adds
@@ -87,6 +223,31 @@ type HasLogger interface {
 	Logger() *slog.Logger
 }
 
+// HasLogAttributes is an interface for services that add their own
+// attributes to their logger.
+//
+// When the logger of a service implementing HasLogAttributes is bound, the
+// attributes it returns are added to every record of the logger, after the
+// attributes added by the mesh.
+type HasLogAttributes interface {
+	Service
+
+	// LogAttributes returns the attributes to add to the logger.
+	LogAttributes() []slog.Attr
+}
+
+// HasGroups is an interface for services that belong to named groups.
+//
+// When a service implementing HasGroups is added to the mesh, it is
+// automatically tagged as a member of each of the groups it declares. Groups
+// can then be started, stopped, and removed as a whole.
+type HasGroups interface {
+	Service
+
+	// Groups returns the names of the groups the service belongs to.
+	Groups() []string
+}
+
 // HasGracefulShutdown is an interface for services that require graceful shutdown handling.
 //
 // The HasGracefulShutdown interface extends the Service interface and adds
@@ -99,6 +260,30 @@ type HasGracefulShutdown interface {
 	OnShutdown()
 }
 
+// HasEventHandlers is an optional interface for services which handle their
+// own custom events.
+//
+// When a service implementing HasEventHandlers is added to the mesh, each of
+// the handlers is bound to the event bus for the event with the given name,
+// and is passed the arguments the event was emitted with. The handlers are
+// unbound when the service is removed from the mesh.
+type HasEventHandlers interface {
+	Service
+
+	// EventHandlers returns the event handlers of the service, keyed by
+	// event name.
+	EventHandlers() map[string]func(args ...any)
+}
+
+// ReplaysEventHistory is an optional interface. If implemented, and the
+// ReplayEventHistory method returns true, the events retained in the event
+// history of the mesh are replayed to each of the EventHandler* interfaces
+// the service implements when it is added. The past events are delivered in
+// order, before any events emitted after the service was added.
+type ReplaysEventHistory interface {
+	ReplayEventHistory() bool
+}
+
 // EventHandlerServiceAdded is an optional interface. If implemented, it will automatically bind to the
 // "Service Added" service mesh event, allowing the handler to respond when a new service is added.
 type EventHandlerServiceAdded interface {
@@ -132,6 +317,80 @@ type EventHandlerServiceLoggerBound interface {
 	OnServiceLoggerBound(service Service)
 }
 
+// EventHandlerServiceInitDeferred is an optional interface. If implemented, it will automatically bind to the
+// "Service Init Deferred" service mesh event, enabling the implementor to respond when a service is added lazily.
+// When the event is emitted, the declared method will be called and passed the arguments from the emitter.
+type EventHandlerServiceInitDeferred interface {
+	OnServiceInitDeferred(service Service)
+}
+
+// EventHandlerServiceDeferredInitStarted is an optional interface. If implemented, it will automatically bind to the
+// "Service Deferred Init Started" service mesh event, enabling the implementor to respond when a lazily added service
+// is first looked up and its initialization begins.
+// When the event is emitted, the declared method will be called and passed the arguments from the emitter.
+type EventHandlerServiceDeferredInitStarted interface {
+	OnServiceDeferredInitStarted(service Service)
+}
+
+// EventHandlerGroupStarted is an optional interface. If implemented, it will automatically bind to the
+// "Group Started" service mesh event, enabling the implementor to respond when a group of services is started.
+// When the event is emitted, the declared method will be called and passed the arguments from the emitter.
+type EventHandlerGroupStarted interface {
+	OnGroupStarted(group string)
+}
+
+// EventHandlerGroupStopped is an optional interface. If implemented, it will automatically bind to the
+// "Group Stopped" service mesh event, enabling the implementor to respond when a group of services is stopped.
+// When the event is emitted, the declared method will be called and passed the arguments from the emitter.
+type EventHandlerGroupStopped interface {
+	OnGroupStopped(group string)
+}
+
+// EventHandlerGroupRemoved is an optional interface. If implemented, it will automatically bind to the
+// "Group Removed" service mesh event, enabling the implementor to respond when a group of services is removed.
+// When the event is emitted, the declared method will be called and passed the arguments from the emitter.
+type EventHandlerGroupRemoved interface {
+	OnGroupRemoved(group string)
+}
+
+// EventHandlerReplicasScaled is an optional interface. If implemented, it will automatically bind to the
+// "Replicas Scaled" service mesh event, enabling the implementor to respond when a replica set is scaled.
+// When the event is emitted, the declared method will be called and passed the arguments from the emitter.
+type EventHandlerReplicasScaled interface {
+	OnReplicasScaled(name string, replicas int)
+}
+
+// EventHandlerRemoteServiceAdded is an optional interface. If implemented, it will automatically bind to the
+// "Remote Service Added" service mesh event, enabling the implementor to respond when a service of another mesh is
+// announced through a Federation.
+// When the event is emitted, the declared method will be called and passed the arguments from the emitter.
+type EventHandlerRemoteServiceAdded interface {
+	OnRemoteServiceAdded(service Service)
+}
+
+// EventHandlerRemoteServiceRemoved is an optional interface. If implemented, it will automatically bind to the
+// "Remote Service Removed" service mesh event, enabling the implementor to respond when a service of another mesh is
+// no longer announced, or its mesh stopped responding.
+// When the event is emitted, the declared method will be called and passed the arguments from the emitter.
+type EventHandlerRemoteServiceRemoved interface {
+	OnRemoteServiceRemoved(service Service)
+}
+
+// EventHandlerLogLevelChange is an optional interface. If implemented, it will automatically bind to the
+// "Log Level Change" service mesh event, enabling the implementor to respond when a log level change is requested.
+// The target is empty for the log level of the mesh, the name of a service, or the name of a group prefixed with
+// "group:". When the event is emitted, the declared method will be called and passed the arguments from the emitter.
+type EventHandlerLogLevelChange interface {
+	OnLogLevelChange(target string, level slog.Level)
+}
+
+// EventHandlerHandlerFailed is an optional interface. If implemented, it will automatically bind to the
+// "Event Handler Failed" service mesh event, enabling the implementor to respond when an event handler fails.
+// When the event is emitted, the declared method will be called and passed the arguments from the emitter.
+type EventHandlerHandlerFailed interface {
+	OnHandlerFailed(event, handler string, err error, attempts int)
+}
+
 // EventHandlerServiceMeshRunLoopInitiated is an optional interface. If implemented, it will automatically bind to the
 // "mesh Run Loop Initiated" service mesh event, enabling the implementor to respond when the service mesh run loop is initiated.
 // When the event is emitted, the declared method will be called and passed the arguments from the emitter.
@@ -0,0 +1,241 @@
+package servicemesh
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+const (
+	defaultJournalMaxSize    = 10 << 20 // 10 MiB
+	defaultJournalMaxBackups = 5
+)
+
+// EventJournal is a service which records every event passing through the
+// event bus of the mesh to a JSON-lines file. Each line holds the event
+// name, its envelope, and its arguments. The file is rotated once it
+// exceeds a maximum size.
+//
+// Arguments are recorded in a serializable form: services are recorded by
+// name and ID, errors by their message, and any other value which cannot be
+// encoded as JSON by its string representation.
+//
+// Journals can be read back with ReadJournal, and replayed into the event bus
+// of a mesh with ReplayJournal.
+type EventJournal struct {
+	path        string
+	maxSize     int64
+	maxBackups  int
+	file        *RotatingFile
+	logger      *slog.Logger
+	mesh        *mesh
+	unsubscribe Unsubscribe
+}
+
+// NewEventJournal creates an event journal service which writes to the file
+// at the given path. Add it to a mesh to start recording.
+func NewEventJournal(path string) *EventJournal {
+	return &EventJournal{
+		path:       path,
+		maxSize:    defaultJournalMaxSize,
+		maxBackups: defaultJournalMaxBackups,
+	}
+}
+
+// SetRotation sets the size in bytes at which the journal file is rotated,
+// and the number of rotated files to keep. It must be called before the
+// journal is added to a mesh.
+func (j *EventJournal) SetRotation(maxSize int64, maxBackups int) {
+	j.maxSize = maxSize
+	j.maxBackups = maxBackups
+}
+
+// Init opens the journal file and starts recording events.
+func (j *EventJournal) Init(m Mesh) {
+	impl, ok := meshOf(m)
+	if !ok {
+		j.logger.Error("starting event journal", "error", ErrUnsupportedMesh)
+		return
+	}
+
+	j.mesh = impl
+	j.file = NewRotatingFile(j.path, Rotation{MaxSize: j.maxSize, MaxBackups: j.maxBackups})
+	j.unsubscribe = SubscribeAll(impl, j.record)
+}
+
+// Name returns the name of the service.
+func (j *EventJournal) Name() string {
+	return "Event Journal"
+}
+
+// SetLogger sets the logger of the service.
+func (j *EventJournal) SetLogger(l *slog.Logger) {
+	j.logger = l
+}
+
+// Logger yields the logger of the service.
+func (j *EventJournal) Logger() *slog.Logger {
+	return j.logger
+}
+
+// OnShutdown stops recording and closes the journal file.
+func (j *EventJournal) OnShutdown() {
+	if j.unsubscribe != nil {
+		j.unsubscribe()
+	}
+
+	if j.file != nil {
+		_ = j.file.Close()
+	}
+}
+
+// journalEntry is a single line of the journal.
+type journalEntry struct {
+	Name     string            `json:"name"`
+	Envelope Envelope          `json:"envelope"`
+	Args     []json.RawMessage `json:"args,omitempty"`
+}
+
+func (j *EventJournal) record(e Event) {
+	entry := journalEntry{
+		Name:     e.Name,
+		Envelope: e.Envelope,
+		Args:     make([]json.RawMessage, 0, len(e.Args)),
+	}
+
+	for _, arg := range e.Args {
+		entry.Args = append(entry.Args, j.encodeArg(arg))
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		j.logger.Error("encoding journal entry", "event", e.Name, "error", err)
+		return
+	}
+
+	if _, err = j.file.Write(append(line, '\n')); err != nil {
+		j.logger.Error("writing journal entry", "event", e.Name, "error", err)
+	}
+}
+
+// serviceRef is the serializable form of a service argument.
+type serviceRef struct {
+	Service string `json:"service"`
+	ID      string `json:"id,omitempty"`
+}
+
+func (j *EventJournal) encodeArg(arg any) json.RawMessage {
+	switch v := arg.(type) {
+	case Service:
+		ref := serviceRef{Service: v.Name()}
+		if j.mesh != nil {
+			ref.ID = j.mesh.ServiceID(v)
+		}
+
+		arg = ref
+	case error:
+		arg = v.Error()
+	}
+
+	data, err := json.Marshal(arg)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(arg))
+	}
+
+	return data
+}
+
+// ReadJournal reads the events recorded by an EventJournal at the given
+// path, including any rotated files, oldest first. The arguments of the
+// events are decoded as generic JSON values (eg. map[string]any, float64).
+func ReadJournal(path string) ([]Event, error) {
+	var files []string
+
+	for i := 1; ; i++ {
+		if _, err := os.Stat(backupName(path, i)); err != nil {
+			break
+		}
+
+		files = append([]string{backupName(path, i)}, files...)
+	}
+
+	files = append(files, path)
+
+	var events []Event
+
+	for _, name := range files {
+		list, err := readJournalFile(name)
+		if err != nil {
+			return events, err
+		}
+
+		events = append(events, list...)
+	}
+
+	return events, nil
+}
+
+func readJournalFile(path string) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var events []Event
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+
+	for line := 1; scanner.Scan(); line++ {
+		var entry journalEntry
+
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return events, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+
+		e := Event{Name: entry.Name, Envelope: entry.Envelope}
+
+		for _, raw := range entry.Args {
+			var arg any
+
+			if err = json.Unmarshal(raw, &arg); err != nil {
+				return events, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+
+			e.Args = append(e.Args, arg)
+		}
+
+		events = append(events, e)
+	}
+
+	return events, scanner.Err()
+}
+
+// ReplayJournal reads the journal at the given path and emits every recorded
+// event on the event bus of the mesh, in order. The source and correlation
+// ID of each event are preserved, while the mesh assigns new sequence
+// numbers and timestamps.
+//
+// Since arguments are replayed in their serialized form, handlers which
+// expect a particular type (such as a Service) will not receive them.
+func ReplayJournal(m Mesh, path string) error {
+	events, err := ReadJournal(path)
+
+	for _, e := range events {
+		meta := Envelope{
+			Source:        e.Envelope.Source,
+			SourceID:      e.Envelope.SourceID,
+			CorrelationID: e.Envelope.CorrelationID,
+		}
+
+		m.Emit(e.Name, append(e.Args, meta)...)
+	}
+
+	return err
+}
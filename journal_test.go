@@ -0,0 +1,113 @@
+package servicemesh
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestEventJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	m := New()
+	journal := NewEventJournal(path)
+	journal.SetRotation(512, 10)
+
+	if err := m.Add(journal).Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		_ = m.Emit("order placed", i, Envelope{CorrelationID: "abc"}).Wait(context.Background())
+	}
+
+	journal.OnShutdown()
+
+	events, err := ReadJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var orders []Event
+
+	for _, e := range events {
+		if e.Name == "order placed" {
+			orders = append(orders, e)
+		}
+	}
+
+	if len(orders) != 10 {
+		t.Fatalf("expected 10 recorded events across rotated files, got %d", len(orders))
+	}
+
+	for i, e := range orders {
+		if e.Args[0] != float64(i) || e.Envelope.CorrelationID != "abc" {
+			t.Errorf("unexpected recorded event: %+v", e)
+		}
+	}
+
+	fresh := New()
+	replayed := make(chan []any, 10)
+	SubscribeAll(fresh, func(e Event) {
+		if e.Name == "order placed" {
+			replayed <- e.Args
+		}
+	})
+
+	if err = ReplayJournal(fresh, path); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if args := <-replayed; args[0] != float64(i) {
+			t.Errorf("unexpected replayed arguments: %v", args)
+		}
+	}
+}
+
+func TestEventJournalRecordsRawEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	m := New()
+	m.SetEventHistoryRetention(10)
+
+	journal := NewEventJournal(path)
+	if err := m.Add(journal).Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := make(chan []any, 1)
+	m.Events().On("order placed", func(args ...any) { raw <- args })
+	m.Events().Emit("order placed", 42, Envelope{CorrelationID: "abc"}).Wait()
+
+	if args := <-raw; len(args) != 2 || args[0] != 42 {
+		t.Errorf("unexpected raw arguments: %v", args)
+	}
+
+	var found bool
+
+	for _, e := range m.EventHistory() {
+		found = found || e.Name == "order placed" && e.Envelope.CorrelationID == "abc"
+	}
+
+	if !found {
+		t.Error("expected the event emitted with Events to be retained in the history")
+	}
+
+	journal.OnShutdown()
+
+	events, err := ReadJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found = false
+
+	for _, e := range events {
+		found = found || e.Name == "order placed" && e.Args[0] == float64(42)
+	}
+
+	if !found {
+		t.Error("expected the event emitted with Events to be recorded")
+	}
+}
@@ -0,0 +1,203 @@
+package servicemesh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultLazyInitTimeout bounds how long a lookup waits for the deferred
+// initialization of a lazy service.
+const defaultLazyInitTimeout = 30 * time.Second
+
+// lazyService tracks the deferred initialization of a service which was
+// added with AddLazy.
+type lazyService struct {
+	once    sync.Once
+	started atomic.Bool
+	op      *Operation
+}
+
+// AddLazy adds a service to the mesh without initializing it. The service is
+// registered and visible to dependency resolution right away, but its
+// dependencies are not resolved and its Init method is not invoked until the
+// service is first looked up with Lookup or Get, or handed to a service which
+// depends on it.
+//
+// The returned Operation completes once the deferred initialization has
+// finished, or once the service is removed without having been initialized.
+func (m *mesh) AddLazy(service Service) *Operation {
+	m.Init(nil) // always ensure service mesh is init
+
+	lazy := &lazyService{op: newOperation()}
+
+	m.mu.Lock()
+	if m.lazy == nil {
+		m.lazy = make(map[Service]*lazyService)
+	}
+	m.lazy[service] = lazy
+	m.mu.Unlock()
+
+	m.register(service)
+	m.emit(EventServiceInitDeferred, service)
+
+	return lazy.op
+}
+
+// SetLazyInitTimeout sets how long a lookup waits for the deferred
+// initialization of a lazy service, after which the lookup fails with
+// ErrLazyInitTimeout. The initialization itself carries on in the background.
+func (m *mesh) SetLazyInitTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lazyInitTimeout = d
+}
+
+// ensureInitialized performs the deferred initialization of a lazy service,
+// blocking until it has finished. Only the first caller initializes the
+// service, and it does nothing for services which were not added lazily.
+//
+// If the initialization failed, for instance because the Init method of the
+// service panicked, its error is returned. The wait is bounded by the lazy
+// init timeout of the mesh, after which an error wrapping ErrLazyInitTimeout
+// is returned, while the initialization carries on in the background, for
+// instance when the dependencies of the service are never resolved.
+func (m *mesh) ensureInitialized(service Service) error {
+	m.mu.RLock()
+	lazy, found := m.lazy[service]
+	m.mu.RUnlock()
+
+	if !found {
+		return nil
+	}
+
+	lazy.once.Do(func() {
+		lazy.started.Store(true)
+
+		go func() {
+			m.emit(EventServiceDeferredInitStarted, service)
+
+			if resolver, ok := service.(HasDependencies); ok {
+				m.resolveDependencies(resolver)
+			}
+
+			lazy.op.complete(m.safeInitService(service))
+		}()
+	})
+
+	m.mu.RLock()
+	timeout := m.lazyInitTimeout
+	m.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := lazy.op.Wait(ctx)
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %s", ErrLazyInitTimeout, service.Name())
+	default:
+		return fmt.Errorf("lazy service %s: %w", service.Name(), err)
+	}
+}
+
+// forgetLazy drops the deferred initialization of a removed service. If it
+// had not started, the Operation returned by AddLazy completes with
+// ErrServiceNotFound.
+func (m *mesh) forgetLazy(service Service) {
+	m.mu.Lock()
+	lazy, found := m.lazy[service]
+	delete(m.lazy, service)
+	m.mu.Unlock()
+
+	if found && !lazy.started.Load() {
+		lazy.op.complete(fmt.Errorf("%w: %s removed before initialization", ErrServiceNotFound, service.Name()))
+	}
+}
+
+// pendingLazy yields the mesh, among this mesh and its ancestors, which holds
+// the deferred initialization of a lazy service which has not finished. It
+// yields nil for any other service.
+func (m *mesh) pendingLazy(service Service) *mesh {
+	for owner := m; owner != nil; owner = owner.parent {
+		owner.mu.RLock()
+		lazy, found := owner.lazy[service]
+		owner.mu.RUnlock()
+
+		if !found {
+			continue
+		}
+
+		select {
+		case <-lazy.op.Done():
+			return nil
+		default:
+			return owner
+		}
+	}
+
+	return nil
+}
+
+// pendingLazyServices yields the lazy services of this mesh and its
+// ancestors whose deferred initialization has not finished.
+func (m *mesh) pendingLazyServices() (list []Service) {
+	for _, service := range m.resolvableServices() {
+		if m.pendingLazy(service) != nil {
+			list = append(list, service)
+		}
+	}
+
+	return list
+}
+
+// offerDependencies hands candidate services to a service, and reports
+// whether its dependencies are resolved.
+//
+// Lazy services which have not been initialized are only handed out when the
+// other candidates do not satisfy the service. Their deferred initialization
+// is then performed before returning, so the service is not initialized with
+// a dependency whose Init has not run.
+func (m *mesh) offerDependencies(resolver HasDependencies, candidates []Service) bool {
+	var eager, deferred []Service
+
+	for _, service := range candidates {
+		if m.pendingLazy(service) != nil {
+			deferred = append(deferred, service)
+		} else {
+			eager = append(eager, service)
+		}
+	}
+
+	resolver.ResolveDependencies(eager)
+
+	if resolver.DependenciesResolved() || len(deferred) == 0 {
+		return resolver.DependenciesResolved()
+	}
+
+	resolver.ResolveDependencies(append(eager, deferred...))
+
+	if !resolver.DependenciesResolved() {
+		return false
+	}
+
+	for _, service := range deferred {
+		owner := m.pendingLazy(service)
+		if owner == nil {
+			continue
+		}
+
+		if err := owner.ensureInitialized(service); err != nil {
+			m.logger.Warn("lazy dependency not initialized", "service", resolver.Name(), "dependency", service.Name(), "error", err)
+		}
+	}
+
+	return true
+}
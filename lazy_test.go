@@ -0,0 +1,172 @@
+package servicemesh
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAddLazy(t *testing.T) {
+	m := New()
+	s := &countingService{}
+
+	op := m.AddLazy(s)
+
+	if n := s.inits.Load(); n != 0 {
+		t.Fatalf("lazy service should not be initialized before lookup, got %d inits", n)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, found := Get[*countingService](m); !found {
+				t.Error("expected lazy service to be found")
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if err := op.Wait(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if n := s.inits.Load(); n != 1 {
+		t.Errorf("expected a single init, got %d", n)
+	}
+}
+
+type countingService struct {
+	inits atomic.Int32
+}
+
+func (c *countingService) Init(_ Mesh) { c.inits.Add(1) }
+
+func (c *countingService) Name() string { return "counting" }
+
+// lazyDependent depends on a *countingService.
+type lazyDependent struct {
+	dependency atomic.Pointer[countingService]
+}
+
+func (d *lazyDependent) Init(_ Mesh)  {}
+func (d *lazyDependent) Name() string { return "lazy dependent" }
+
+func (d *lazyDependent) DependenciesResolved() bool { return d.dependency.Load() != nil }
+
+func (d *lazyDependent) ResolveDependencies(services []Service) {
+	for _, service := range services {
+		if candidate, ok := service.(*countingService); ok {
+			d.dependency.Store(candidate)
+		}
+	}
+}
+
+func TestLazyServiceResolvedAsDependency(t *testing.T) {
+	m := New()
+	s := &countingService{}
+
+	op := m.AddLazy(s)
+
+	dependent := &lazyDependent{}
+	if err := m.Add(dependent).Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if dependent.dependency.Load() != s || s.inits.Load() != 1 {
+		t.Errorf("expected the lazy service to be initialized before it was handed over")
+	}
+
+	if err := op.Wait(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// lazy services are initialized for a batch just the same
+	other := &countingService{}
+	m.Remove(s)
+	m.AddLazy(other)
+
+	batched := &lazyDependent{}
+	if err := m.AddAll(batched).Wait(context.Background()); err != nil || other.inits.Load() != 1 {
+		t.Errorf("expected the lazy service to be initialized for the batch (%v)", err)
+	}
+}
+
+// unresolvable is a lazy service whose dependencies are never resolved.
+type unresolvable struct{}
+
+func (u *unresolvable) Init(_ Mesh)                     {}
+func (u *unresolvable) Name() string                    { return "unresolvable" }
+func (u *unresolvable) DependenciesResolved() bool      { return false }
+func (u *unresolvable) ResolveDependencies(_ []Service) {}
+
+func TestLazyServiceLookupTimeout(t *testing.T) {
+	m := New()
+	m.SetLazyInitTimeout(20 * time.Millisecond)
+
+	s := &unresolvable{}
+	m.AddLazy(s)
+
+	if _, found := m.Lookup("unresolvable"); found {
+		t.Errorf("expected a lazy service which cannot be initialized not to be found")
+	}
+
+	if _, err := Resolve[*unresolvable](m); !errors.Is(err, ErrLazyInitTimeout) {
+		t.Errorf("expected the lookup to time out, got %v", err)
+	}
+}
+
+// brokenService panics once it is initialized.
+type brokenService struct{}
+
+func (b *brokenService) Init(_ Mesh)  { panic("init boom") }
+func (b *brokenService) Name() string { return "broken" }
+
+func TestLazyServiceLookupFailed(t *testing.T) {
+	m := New()
+	m.AddLazy(&brokenService{})
+
+	if _, found := m.Lookup("broken"); found {
+		t.Errorf("expected a lazy service whose Init panicked not to be found")
+	}
+
+	if _, err := Resolve[*brokenService](m); !errors.Is(err, ErrInitPanicked) {
+		t.Errorf("expected the lookup to report the panic, got %v", err)
+	}
+}
+
+func TestLazyServiceLookupFromChild(t *testing.T) {
+	m := New()
+	s := &countingService{}
+	m.AddLazy(s)
+
+	child := m.NewChild("child")
+
+	if found, ok := child.Lookup("counting"); !ok || found != s || s.inits.Load() != 1 {
+		t.Errorf("expected the lazy service of the parent to be found and initialized")
+	}
+}
+
+func TestLazyServiceRemoved(t *testing.T) {
+	m := New()
+	s := &countingService{}
+
+	op := m.AddLazy(s)
+	_ = m.Remove(s).Wait(context.Background())
+
+	if err := op.Wait(context.Background()); !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("expected the deferred initialization to be dropped, got %v", err)
+	}
+
+	if _, found := m.(*mesh).lazy[s]; found {
+		t.Errorf("expected the lazy service to be forgotten")
+	}
+}
@@ -0,0 +1,173 @@
+package servicemesh
+
+import (
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReconnectMin = 100 * time.Millisecond
+	defaultReconnectMax = 10 * time.Second
+)
+
+type netAddress struct {
+	network string
+	address string
+}
+
+// netLinks maintains the connections of a service to its peers in other
+// processes: it accepts connections on the addresses it listens on, and keeps
+// connecting to the addresses it dials, with a backoff.
+type netLinks struct {
+	mu           sync.Mutex
+	listen       []netAddress
+	dial         []netAddress
+	reconnectMin time.Duration
+	reconnectMax time.Duration
+	listeners    []net.Listener
+	conns        map[net.Conn]bool
+	quit         chan struct{}
+	wg           sync.WaitGroup
+}
+
+func newNetLinks() netLinks {
+	return netLinks{
+		reconnectMin: defaultReconnectMin,
+		reconnectMax: defaultReconnectMax,
+		conns:        make(map[net.Conn]bool),
+		quit:         make(chan struct{}),
+	}
+}
+
+// start listens and dials, handing every connection to serve. The connection
+// is closed once serve returns.
+func (l *netLinks) start(logger *slog.Logger, serve func(net.Conn)) {
+	for _, addr := range l.listen {
+		listener, err := net.Listen(addr.network, addr.address)
+		if err != nil {
+			logger.Error("listening", "address", addr.address, "error", err)
+			continue
+		}
+
+		l.mu.Lock()
+		l.listeners = append(l.listeners, listener)
+		l.mu.Unlock()
+
+		l.wg.Add(1)
+		go l.accept(logger, listener, serve)
+	}
+
+	for _, addr := range l.dial {
+		l.wg.Add(1)
+		go l.connect(logger, addr, serve)
+	}
+}
+
+// stop closes every listener and connection, and waits for them to finish.
+func (l *netLinks) stop() {
+	close(l.quit)
+
+	l.mu.Lock()
+
+	for _, listener := range l.listeners {
+		_ = listener.Close()
+	}
+
+	for conn := range l.conns {
+		_ = conn.Close()
+	}
+
+	l.mu.Unlock()
+
+	l.wg.Wait()
+}
+
+func (l *netLinks) stopping() bool {
+	select {
+	case <-l.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *netLinks) accept(logger *slog.Logger, listener net.Listener, serve func(net.Conn)) {
+	defer l.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !l.stopping() {
+				logger.Error("accepting connection", "error", err)
+			}
+
+			return
+		}
+
+		l.wg.Add(1)
+
+		go func() {
+			defer l.wg.Done()
+			l.serve(conn, serve)
+		}()
+	}
+}
+
+// connect keeps a connection to the given address, until stopped.
+func (l *netLinks) connect(logger *slog.Logger, addr netAddress, serve func(net.Conn)) {
+	defer l.wg.Done()
+
+	backoff := l.reconnectMin
+
+	for !l.stopping() {
+		conn, err := net.Dial(addr.network, addr.address)
+		if err == nil {
+			logger.Debug("connected", "address", addr.address)
+			backoff = l.reconnectMin
+
+			l.serve(conn, serve)
+
+			if l.stopping() {
+				return
+			}
+
+			logger.Warn("lost connection", "address", addr.address)
+		} else {
+			logger.Debug("connecting", "address", addr.address, "error", err, "retry", backoff)
+		}
+
+		select {
+		case <-l.quit:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, l.reconnectMax)
+	}
+}
+
+func (l *netLinks) serve(conn net.Conn, serve func(net.Conn)) {
+	l.mu.Lock()
+
+	if l.stopping() {
+		l.mu.Unlock()
+		_ = conn.Close()
+
+		return
+	}
+
+	l.conns[conn] = true
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		delete(l.conns, conn)
+		l.mu.Unlock()
+
+		_ = conn.Close()
+	}()
+
+	serve(conn)
+}
@@ -0,0 +1,220 @@
+package servicemesh
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LogAttr selects attributes which the mesh adds to the log records of
+// every service. Attributes are combined with a bitwise or, eg.
+// LogAttrMesh|LogAttrPhase.
+type LogAttr uint
+
+const (
+	// LogAttrMesh adds the name of the mesh as "mesh". Child meshes always
+	// name themselves, this also names the root mesh.
+	LogAttrMesh LogAttr = 1 << iota
+
+	// LogAttrServiceID adds the ID the mesh assigned to the service as
+	// "service_id".
+	LogAttrServiceID
+
+	// LogAttrGroup adds the groups the service belongs to as "group",
+	// following the service as it joins and leaves groups.
+	LogAttrGroup
+
+	// LogAttrPhase adds the lifecycle phase of the service as "phase", eg.
+	// "initializing" or "ready", following the service through its
+	// lifecycle.
+	LogAttrPhase
+
+	// LogAttrPID adds the ID of the process as "pid".
+	LogAttrPID
+)
+
+// lifecycle phases of a service, see LogAttrPhase
+const (
+	phaseAdded        = "added"
+	phaseResolving    = "resolving dependencies"
+	phaseInitializing = "initializing"
+	phaseReady        = "ready"
+	phaseFailed       = "failed"
+	phaseShuttingDown = "shutting down"
+	phaseRemoved      = "removed"
+)
+
+// logContexts holds the state of every service which is added to its log
+// records as it changes.
+type logContexts struct {
+	mu       sync.Mutex
+	services map[Service]*logContext
+}
+
+// logContext is the lifecycle phase and groups of a service, and the current
+// handler of its logger.
+type logContext struct {
+	phase   atomic.Value // string
+	groups  atomic.Value // string
+	handler atomic.Pointer[handlerRef]
+}
+
+func (c *logContext) load(value *atomic.Value) string {
+	s, _ := value.Load().(string)
+	return s
+}
+
+// contextHandler is a slog.Handler which adds the current lifecycle phase
+// and groups of a service to each record.
+type contextHandler struct {
+	next  slog.Handler
+	state *logContext
+	attrs LogAttr
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	r = r.Clone()
+
+	if h.attrs&LogAttrGroup != 0 {
+		if groups := h.state.load(&h.state.groups); groups != "" {
+			r.AddAttrs(slog.String("group", groups))
+		}
+	}
+
+	if h.attrs&LogAttrPhase != 0 {
+		if phase := h.state.load(&h.state.phase); phase != "" {
+			r.AddAttrs(slog.String("phase", phase))
+		}
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs), state: h.state, attrs: h.attrs}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name), state: h.state, attrs: h.attrs}
+}
+
+// SetLogAttrs selects the attributes the mesh adds to the log records of the
+// mesh and every service, in addition to the name of the service, as well as
+// any services added in the future. By default, none are added.
+func (m *mesh) SetLogAttrs(attrs LogAttr) {
+	m.logMu.Lock()
+	m.logAttrs = attrs
+	m.logMu.Unlock()
+
+	m.updateServiceLoggers()
+}
+
+// withLogAttrs adds the attributes selected with SetLogAttrs, and those the
+// service contributes through HasLogAttributes, to the logger of a service.
+func (m *mesh) withLogAttrs(service Service, state *logContext, handler slog.Handler) *slog.Logger {
+	m.logMu.RLock()
+	attrs := m.logAttrs
+	m.logMu.RUnlock()
+
+	if attrs&(LogAttrGroup|LogAttrPhase) != 0 {
+		handler = &contextHandler{next: handler, state: state, attrs: attrs}
+	}
+
+	logger := slog.New(handler)
+
+	if m.parent != nil || attrs&LogAttrMesh != 0 {
+		logger = logger.With(slog.String("mesh", m.name))
+	}
+
+	if service != m {
+		logger = logger.With(slog.String("service", service.Name()))
+	}
+
+	if id := m.ServiceID(service); id != "" && attrs&LogAttrServiceID != 0 {
+		logger = logger.With(slog.String("service_id", id))
+	}
+
+	if attrs&LogAttrPID != 0 {
+		logger = logger.With(slog.Int("pid", os.Getpid()))
+	}
+
+	if candidate, ok := service.(HasLogAttributes); ok {
+		for _, attr := range candidate.LogAttributes() {
+			logger = logger.With(attr)
+		}
+	}
+
+	return logger
+}
+
+// logContextOf yields the log context of a service, creating it if needed.
+func (m *mesh) logContextOf(service Service) *logContext {
+	m.logContexts.mu.Lock()
+
+	if m.logContexts.services == nil {
+		m.logContexts.services = make(map[Service]*logContext)
+	}
+
+	state, found := m.logContexts.services[service]
+	if !found {
+		state = new(logContext)
+		m.logContexts.services[service] = state
+	}
+
+	m.logContexts.mu.Unlock()
+
+	// the service may have been tagged into groups before it was added
+	if !found {
+		state.groups.Store(joinGroups(m.groupsOf()[service]))
+	}
+
+	return state
+}
+
+// phaseOf yields the lifecycle phase of a service.
+func (m *mesh) phaseOf(service Service) string {
+	state := m.logContextOf(service)
+	return state.load(&state.phase)
+}
+
+// setPhase records the lifecycle phase of a service.
+func (m *mesh) setPhase(service Service, phase string) {
+	m.logContextOf(service).phase.Store(phase)
+}
+
+// refreshLogGroups records the groups of every service.
+func (m *mesh) refreshLogGroups() {
+	groups := m.groupsOf()
+
+	m.logContexts.mu.Lock()
+	defer m.logContexts.mu.Unlock()
+
+	for service, state := range m.logContexts.services {
+		state.groups.Store(joinGroups(groups[service]))
+	}
+}
+
+// joinGroups yields the value of the group attribute for a list of groups.
+func joinGroups(groups []string) string {
+	sorted := append([]string(nil), groups...)
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, ",")
+}
+
+// forgetLogContext drops the log context of a service which left the mesh.
+// Its logger keeps the context, with the phase it was last in.
+func (m *mesh) forgetLogContext(service Service) {
+	m.logContexts.mu.Lock()
+	defer m.logContexts.mu.Unlock()
+
+	delete(m.logContexts.services, service)
+}
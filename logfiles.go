@@ -0,0 +1,105 @@
+package servicemesh
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// logFiles holds the log file of every service, by name.
+type logFiles struct {
+	mu       sync.Mutex
+	dir      string
+	rotation Rotation
+	files    map[string]*RotatingFile
+}
+
+// fileFor yields the log file of the services with the given name.
+func (l *logFiles) fileFor(name string) *RotatingFile {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, found := l.files[name]
+	if !found {
+		file = NewRotatingFile(filepath.Join(l.dir, logFileName(name)), l.rotation)
+		l.files[name] = file
+	}
+
+	return file
+}
+
+// close closes every log file.
+func (l *logFiles) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var errs []error
+
+	for _, file := range l.files {
+		if err := file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// logFileName yields the name of the log file of a service, replacing any
+// characters of the service name which are unsafe in a file name.
+func logFileName(service string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, service)
+
+	return strings.Trim(name, ".") + ".log"
+}
+
+// SetServiceLogFiles makes the built-in handler write the records of the mesh
+// and of every service to a file of its own, named after the service (eg.
+// "Event_Journal.log") in the given directory, instead of to the log
+// destination. Each file is a RotatingFile, rotated as described by the
+// rotation. Services sharing a name share a file, and child meshes write to
+// the files of their parent. Passing an empty directory restores the log
+// destination.
+func (m *mesh) SetServiceLogFiles(dir string, rotation Rotation) {
+	m.logMu.Lock()
+
+	previous := m.logFiles
+	m.logFiles = nil
+
+	if dir != "" {
+		m.logFiles = &logFiles{dir: dir, rotation: rotation, files: make(map[string]*RotatingFile)}
+	}
+
+	m.logMu.Unlock()
+
+	m.updateServiceLoggers()
+
+	if previous != nil {
+		if err := previous.close(); err != nil {
+			m.logger.Warn("closing log files", "error", err)
+		}
+	}
+}
+
+// closeLogFiles closes the log files of the services, when the root mesh
+// shuts down.
+func (m *mesh) closeLogFiles() {
+	if m.parent != nil {
+		return
+	}
+
+	m.logMu.RLock()
+	files := m.logFiles
+	m.logMu.RUnlock()
+
+	if files != nil {
+		_ = files.close()
+	}
+}
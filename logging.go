@@ -6,71 +6,279 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"sync/atomic"
 )
 
+// LogFormat is the format of the log records written by the built-in log
+// handler of the mesh.
+type LogFormat int
+
+const (
+	// LogFormatText writes log records as key=value pairs, see
+	// slog.TextHandler. This is the default format.
+	LogFormatText LogFormat = iota
+
+	// LogFormatJSON writes log records as JSON objects, see
+	// slog.JSONHandler.
+	LogFormatJSON
+)
+
+// LogHandlerWrapper wraps the log handler of the mesh, eg. to add attributes
+// to every record or to send records to a second destination.
+type LogHandlerWrapper func(next slog.Handler) slog.Handler
+
 // newLogger is a factory function that generates a slog instance for a service.
+//
+// The logging configuration of the mesh is composed in a fixed order, so
+// that the order in which it is set up does not matter:
+//
+//  1. the base handler is the handler given to SetLogHandler, or else the
+//     built-in handler, writing in the format given to SetLogFormat to the
+//     destination given to SetLogDestination, or to the file of the service
+//     if SetServiceLogFiles is in use
+//  2. the wrappers given to WrapLogHandler wrap the base handler, the first
+//     wrapper being the outermost
+//  3. the records of each service are retained in its recent logs, see
+//     SetRecentLogRetention
+//  4. the log level of each service (see SetLogLevel and
+//     SetServiceLogLevel) filters the records before they are retained or
+//     reach the wrappers
+//  5. the attributes selected with SetLogAttrs are added to the records of
+//     each service
+//
+// The base handler and wrappers are shared by every service, while the log
+// level is applied per service.
+//
+// The logger of a service keeps following the logging configuration of the
+// mesh: when it changes, the handler behind the logger is swapped, rather
+// than the logger being replaced.
 func (m *mesh) newLogger(service Service) *slog.Logger {
-	name := service.Name()
+	state := m.logContextOf(service)
+	state.handler.Store(&handlerRef{m.buildLogHandler(service, state)})
+
+	return slog.New(&switchHandler{state: state})
+}
+
+// newDetachedLogger creates the logger of a service which is not part of the
+// mesh. The mesh keeps no log context for the service, so the logger keeps
+// the logging configuration it was created with.
+func (m *mesh) newDetachedLogger(service Service) *slog.Logger {
+	state := new(logContext)
+	state.handler.Store(&handlerRef{m.buildLogHandler(service, state)})
+
+	return slog.New(&switchHandler{state: state})
+}
+
+// buildLogHandler composes the handler of a service from the current logging
+// configuration of the mesh.
+func (m *mesh) buildLogHandler(service Service, state *logContext) slog.Handler {
+	handler := m.withRecentLogs(service, m.logHandlerChain(service))
+
+	return m.withLogAttrs(service, state, &levelHandler{next: handler, level: m.levelFor(service)}).Handler()
+}
+
+// handlerRef boxes a slog.Handler, so that it can be swapped atomically.
+type handlerRef struct {
+	slog.Handler
+}
+
+// switchHandler is a slog.Handler which passes records to the current
+// handler of a service, applying the attributes and groups added to the
+// logger on top of it.
+type switchHandler struct {
+	state   *logContext
+	derive  []func(slog.Handler) slog.Handler
+	derived atomic.Pointer[derivedHandler]
+}
+
+// derivedHandler caches the handler derived from the current handler of a
+// service.
+type derivedHandler struct {
+	from    *handlerRef
+	handler slog.Handler
+}
+
+func (h *switchHandler) current() slog.Handler {
+	ref := h.state.handler.Load()
 
-	opts := &slog.HandlerOptions{
-		Level: m.logLevel,
+	if len(h.derive) == 0 {
+		return ref.Handler
 	}
 
-	if m.logOutput == nil {
-		m.logOutput = os.Stdout
+	if cached := h.derived.Load(); cached != nil && cached.from == ref {
+		return cached.handler
 	}
 
-	if m.logHandler == nil {
-		m.logHandler = slog.NewTextHandler(m.logOutput, opts) // or NewJSONHandler for JSON output
+	handler := ref.Handler
+	for _, derive := range h.derive {
+		handler = derive(handler)
 	}
 
-	logger := slog.New(m.logHandler)
+	h.derived.Store(&derivedHandler{from: ref, handler: handler})
+
+	return handler
+}
+
+func (h *switchHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.current().Enabled(ctx, level)
+}
+
+func (h *switchHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.current().Handle(ctx, r)
+}
+
+func (h *switchHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *switchHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *switchHandler) with(derive func(slog.Handler) slog.Handler) slog.Handler {
+	list := append(append([]func(slog.Handler) slog.Handler(nil), h.derive...), derive)
+	return &switchHandler{state: h.state, derive: list}
+}
 
-	if service != m {
-		logger = logger.With(slog.String("service", name))
+// logHandlerChain composes the base handler of the mesh for a service with
+// its wrappers.
+func (m *mesh) logHandlerChain(service Service) slog.Handler {
+	m.logMu.RLock()
+	defer m.logMu.RUnlock()
+
+	handler := m.logHandler
+
+	if handler == nil {
+		output := m.logOutput
+		if output == nil {
+			output = os.Stdout
+		}
+
+		if m.logFiles != nil {
+			output = m.logFiles.fileFor(service.Name())
+		}
+
+		// the level of each service is applied by a levelHandler, see levelFor
+		opts := &slog.HandlerOptions{Level: lowestLevel}
+
+		switch m.logFormat {
+		case LogFormatJSON:
+			handler = slog.NewJSONHandler(output, opts)
+		default:
+			handler = slog.NewTextHandler(output, opts)
+		}
 	}
 
-	return logger
+	for i := len(m.logWrappers) - 1; i >= 0; i-- {
+		handler = m.logWrappers[i](handler)
+	}
+
+	return handler
 }
 
 // SetLogHandler sets the slog log handler interface for the service mesh and
-// all existing services, as well as any services added in the future.
+// all existing services, as well as any services added in the future. The
+// handler replaces the built-in handler, so the destination and format of
+// the mesh do not apply to it, while the log levels of the mesh do. Passing
+// nil restores the built-in handler.
 func (m *mesh) SetLogHandler(handler slog.Handler) {
+	m.logMu.Lock()
 	m.logHandler = handler
-	m.logger = m.newLogger(m)
+	m.logMu.Unlock()
 
 	m.updateServiceLoggers()
 }
 
 // SetLogLevel sets the slog logger log level for the service mesh and
 // all existing services, as well as any services added in the future.
-func (m *mesh) SetLogLevel(level slog.Level) { // Change level type as appropriate
+// Services whose log level is overridden with SetServiceLogLevel or
+// SetGroupLogLevel keep their own level.
+func (m *mesh) SetLogLevel(level slog.Level) {
+	m.logMu.Lock()
 	m.logLevel = level
+	m.logMu.Unlock()
+
+	// the existing loggers follow the new level without being replaced
+	m.refreshLogLevels()
+
 	m.logger.Log(context.Background(), slog.LevelInfo, fmt.Sprintf("setting log level to %d", level))
-	m.logger = m.newLogger(m)
 
-	m.updateServiceLoggers()
+	for _, child := range m.children() {
+		child.SetLogLevel(level)
+	}
 }
 
 // SetLogDestination sets the slog logger destination for the service mesh and
-// all existing services, as well as any services added in the future.
+// all existing services, as well as any services added in the future. The
+// destination applies to the built-in handler, and is kept while a handler
+// set with SetLogHandler is in use.
 func (m *mesh) SetLogDestination(dst io.Writer) {
+	m.logMu.Lock()
 	m.logOutput = dst
+	m.logMu.Unlock()
 
-	newLogger := m.newLogger(m)
-	m.logger = newLogger
+	m.updateServiceLoggers()
+}
+
+// SetLogFormat sets the format of the built-in handler for the service mesh
+// and all existing services, as well as any services added in the future.
+// Like the destination, it is kept while a handler set with SetLogHandler is
+// in use.
+func (m *mesh) SetLogFormat(format LogFormat) {
+	m.logMu.Lock()
+	m.logFormat = format
+	m.logMu.Unlock()
+
+	m.updateServiceLoggers()
+}
+
+// WrapLogHandler adds wrappers around the log handler of the mesh, which
+// apply to the built-in handler and to a handler set with SetLogHandler
+// alike. The wrappers are applied in the order they were added, the first
+// being the outermost.
+func (m *mesh) WrapLogHandler(wrappers ...LogHandlerWrapper) {
+	m.logMu.Lock()
+	m.logWrappers = append(m.logWrappers, wrappers...)
+	m.logMu.Unlock()
 
 	m.updateServiceLoggers()
 }
 
+// inheritLogging gives a child mesh the logging configuration of its parent.
+func (m *mesh) inheritLogging(child *mesh) {
+	m.logMu.RLock()
+	defer m.logMu.RUnlock()
+
+	child.logMu.Lock()
+	defer child.logMu.Unlock()
+
+	child.logHandler = m.logHandler
+	child.logLevel = m.logLevel
+	child.logOutput = m.logOutput
+	child.logFormat = m.logFormat
+	child.logWrappers = append([]LogHandlerWrapper(nil), m.logWrappers...)
+	child.logAttrs = m.logAttrs
+	child.recentLogs = m.recentLogs
+	child.logFiles = m.logFiles
+}
+
+// updateServiceLoggers swaps the handlers behind the loggers of the mesh and
+// its services for ones built from the current logging configuration.
 func (m *mesh) updateServiceLoggers() {
-	// set the log level for each service that has a logger
-	for _, service := range m.Services() {
-		candidate, ok := service.(HasLogger)
-		if !ok {
-			continue
-		}
+	m.logContexts.mu.Lock()
+	states := make(map[Service]*logContext, len(m.logContexts.services))
+	for service, state := range m.logContexts.services {
+		states[service] = state
+	}
+	m.logContexts.mu.Unlock()
+
+	for service, state := range states {
+		state.handler.Store(&handlerRef{m.buildLogHandler(service, state)})
+	}
 
-		candidate.SetLogger(m.newLogger(candidate))
+	// child meshes inherit the logging configuration of their parent
+	for _, child := range m.children() {
+		m.inheritLogging(child)
+		child.updateServiceLoggers()
 	}
 }
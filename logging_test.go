@@ -0,0 +1,387 @@
+package servicemesh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogConfigurationOrder(t *testing.T) {
+	type setter struct {
+		name  string
+		apply func(m Mesh, builtin, custom io.Writer)
+	}
+
+	level := setter{"level", func(m Mesh, _, _ io.Writer) { m.SetLogLevel(slog.LevelWarn) }}
+	destination := setter{"destination", func(m Mesh, builtin, _ io.Writer) { m.SetLogDestination(builtin) }}
+	handler := setter{"handler", func(m Mesh, _, custom io.Writer) {
+		m.SetLogHandler(slog.NewTextHandler(custom, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}}
+
+	orders := [][]setter{
+		{level, destination, handler},
+		{level, handler, destination},
+		{destination, level, handler},
+		{destination, handler, level},
+		{handler, level, destination},
+		{handler, destination, level},
+	}
+
+	for _, order := range orders {
+		names := make([]string, 0, len(order))
+		for _, s := range order {
+			names = append(names, s.name)
+		}
+
+		t.Run(strings.Join(names, ","), func(t *testing.T) {
+			var builtin, custom logBuffer
+
+			m := New()
+			service := &loggingService{name: "svc"}
+			_ = m.Add(service).Wait(context.Background())
+
+			for _, s := range order {
+				s.apply(m, &builtin, &custom)
+			}
+
+			// the custom handler receives the records, filtered by the mesh level
+			if service.logs(&custom, slog.LevelInfo) {
+				t.Errorf("expected the mesh log level to apply to the custom handler")
+			}
+
+			if !service.logs(&custom, slog.LevelWarn) {
+				t.Errorf("expected the custom handler to receive the records")
+			}
+
+			// removing the custom handler restores the built-in handler with
+			// the configured destination and level
+			m.SetLogHandler(nil)
+
+			if service.logs(&builtin, slog.LevelInfo) || !service.logs(&builtin, slog.LevelWarn) {
+				t.Errorf("expected the built-in handler to keep the destination and level")
+			}
+		})
+	}
+}
+
+func TestLogFormatAndWrappers(t *testing.T) {
+	var out logBuffer
+
+	m := New()
+	m.SetLogDestination(&out)
+
+	service := &loggingService{name: "svc"}
+	_ = m.Add(service).Wait(context.Background())
+
+	m.SetLogFormat(LogFormatJSON)
+
+	out.Reset()
+	service.logger.Info("probe")
+
+	var record map[string]any
+	if err := json.Unmarshal([]byte(out.String()), &record); err != nil {
+		t.Fatalf("expected a JSON record, got %q", out.String())
+	}
+
+	if record["service"] != "svc" {
+		t.Errorf("expected the record to name the service, got %v", record)
+	}
+
+	var order []string
+
+	wrapper := func(name string) LogHandlerWrapper {
+		return func(next slog.Handler) slog.Handler {
+			return &recordingHandler{Handler: next, name: name, order: &order}
+		}
+	}
+
+	m.WrapLogHandler(wrapper("outer"), wrapper("inner"))
+	m.SetLogFormat(LogFormatText)
+
+	out.Reset()
+	service.logger.Info("probe")
+
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Errorf("expected the wrappers to apply in the order they were added, got %v", order)
+	}
+
+	if !strings.Contains(out.String(), "msg=probe") {
+		t.Errorf("expected the wrapped built-in handler to write text, got %q", out.String())
+	}
+
+	// wrappers also apply to a custom handler
+	var custom logBuffer
+
+	order = nil
+	m.SetLogHandler(slog.NewJSONHandler(&custom, nil))
+	service.logger.Info("probe")
+
+	if len(order) != 2 || !strings.Contains(custom.String(), `"msg":"probe"`) {
+		t.Errorf("expected the wrappers to apply to the custom handler")
+	}
+}
+
+func TestChildInheritsLogConfiguration(t *testing.T) {
+	var out logBuffer
+
+	m := New()
+	child := m.NewChild("child")
+
+	m.SetLogDestination(&out)
+	m.SetLogLevel(slog.LevelWarn)
+
+	service := &loggingService{name: "svc"}
+	_ = child.Add(service).Wait(context.Background())
+
+	if service.logs(&out, slog.LevelInfo) || !service.logs(&out, slog.LevelWarn) {
+		t.Errorf("expected the child mesh to inherit the destination and level")
+	}
+}
+
+// recordingHandler records the order in which wrapping handlers are called.
+type recordingHandler struct {
+	slog.Handler
+	name  string
+	order *[]string
+}
+
+func (h *recordingHandler) Handle(ctx context.Context, r slog.Record) error {
+	*h.order = append(*h.order, h.name)
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *recordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &recordingHandler{Handler: h.Handler.WithAttrs(attrs), name: h.name, order: h.order}
+}
+
+func (h *recordingHandler) WithGroup(name string) slog.Handler {
+	return &recordingHandler{Handler: h.Handler.WithGroup(name), name: h.name, order: h.order}
+}
+
+type attributedService struct {
+	loggingService
+	groups []string
+}
+
+func (s *attributedService) Groups() []string { return s.groups }
+
+func (s *attributedService) LogAttributes() []slog.Attr {
+	return []slog.Attr{slog.String("team", "payments")}
+}
+
+func TestLogAttrs(t *testing.T) {
+	var out logBuffer
+
+	m := New("shop")
+	m.SetLogDestination(&out)
+	m.SetLogFormat(LogFormatJSON)
+	m.SetLogAttrs(LogAttrMesh | LogAttrServiceID | LogAttrGroup | LogAttrPhase | LogAttrPID)
+
+	service := &attributedService{loggingService: loggingService{name: "svc"}, groups: []string{"web", "api"}}
+	_ = m.Add(service).Wait(context.Background())
+
+	record := func() map[string]any {
+		out.Reset()
+		service.logger.Info("probe")
+
+		var record map[string]any
+		if err := json.Unmarshal([]byte(out.String()), &record); err != nil {
+			t.Fatalf("expected a JSON record, got %q", out.String())
+		}
+
+		return record
+	}
+
+	got := record()
+
+	want := map[string]any{
+		"mesh":       "shop",
+		"service":    "svc",
+		"service_id": m.(*mesh).ServiceID(service),
+		"group":      "api,web",
+		"phase":      phaseReady,
+		"pid":        float64(os.Getpid()),
+		"team":       "payments",
+	}
+
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, got[key])
+		}
+	}
+
+	// the group and phase follow the service
+	m.AddToGroup("admin", service)
+	_ = m.Remove(service).Wait(context.Background())
+
+	got = record()
+
+	if got["group"] != "admin,api,web" || got["phase"] != phaseRemoved {
+		t.Errorf("expected the group and phase to be updated, got %v", got)
+	}
+
+	// no attributes are added by default
+	m.SetLogAttrs(0)
+
+	other := &loggingService{name: "other"}
+	_ = m.Add(other).Wait(context.Background())
+
+	out.Reset()
+	other.logger.Info("probe")
+
+	if strings.Contains(out.String(), "phase") || strings.Contains(out.String(), "mesh") {
+		t.Errorf("expected only the service name by default, got %q", out.String())
+	}
+}
+
+func TestRecentLogs(t *testing.T) {
+	var out logBuffer
+
+	m := New()
+	m.SetLogHandler(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	db := &loggingService{name: "db"}
+	_ = m.Add(db).Wait(context.Background())
+
+	if m.RecentLogs("db", 0) != nil {
+		t.Errorf("expected no recent logs to be retained by default")
+	}
+
+	m.SetRecentLogRetention(3)
+
+	for i := 0; i < 5; i++ {
+		db.logger.WithGroup("query").Info("probe", "n", i)
+	}
+
+	db.logger.Debug("filtered")
+
+	records := m.RecentLogs("db", 0)
+	if len(records) != 3 {
+		t.Fatalf("expected the last 3 records to be retained, got %d", len(records))
+	}
+
+	if records[0].Attrs["query.n"] != int64(2) || records[2].Attrs["query.n"] != int64(4) {
+		t.Errorf("expected the most recent records, oldest first, got %v", records)
+	}
+
+	if records[2].Service != "db" || records[2].Message != "probe" || records[2].Attrs["service"] != "db" {
+		t.Errorf("expected the record to describe the service, got %+v", records[2])
+	}
+
+	if strings.Contains(out.String(), "probe") {
+		t.Errorf("expected the records to be retained regardless of the log handler")
+	}
+
+	if last := m.RecentLogs("db", 1); len(last) != 1 || last[0].Attrs["query.n"] != int64(4) {
+		t.Errorf("expected the last record, got %v", last)
+	}
+
+	// the recent logs can be requested through the event bus
+	reply, err := m.Request(context.Background(), RecentLogsTopic, RecentLogsQuery{Service: "db", N: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if records, ok := reply.([]LogRecord); !ok || len(records) != 2 {
+		t.Errorf("expected 2 records in reply, got %v", reply)
+	}
+
+	if _, err := m.Request(context.Background(), RecentLogsTopic, "db"); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("expected an invalid payload to be reported, got %v", err)
+	}
+
+	m.SetRecentLogRetention(0)
+
+	if _, err := m.Request(context.Background(), RecentLogsTopic, RecentLogsQuery{Service: "db"}); !errors.Is(err, ErrNoResponder) {
+		t.Errorf("expected the request handler to be removed, got %v", err)
+	}
+}
+
+func TestServiceLogFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	var out logBuffer
+
+	m := New()
+	m.SetLogDestination(&out)
+
+	db := &loggingService{name: "db"}
+	web := &loggingService{name: "web/api"}
+	_ = m.AddAll(db, web).Wait(context.Background())
+
+	m.SetServiceLogFiles(dir, Rotation{MaxSize: 1 << 20, MaxBackups: 1})
+
+	db.logger.Info("from db")
+	web.logger.Info("from web")
+
+	dbLog, _ := os.ReadFile(filepath.Join(dir, "db.log"))
+	webLog, _ := os.ReadFile(filepath.Join(dir, "web_api.log"))
+
+	if !strings.Contains(string(dbLog), "from db") || strings.Contains(string(dbLog), "from web") {
+		t.Errorf("expected the records of the service in its own file, got %q", dbLog)
+	}
+
+	if !strings.Contains(string(webLog), "from web") {
+		t.Errorf("expected the records of the service in its own file, got %q", webLog)
+	}
+
+	if strings.Contains(out.String(), "from") {
+		t.Errorf("expected the log destination to be bypassed, got %q", out.String())
+	}
+
+	m.SetServiceLogFiles("", Rotation{})
+	db.logger.Info("back to the destination")
+
+	if !strings.Contains(out.String(), "back to the destination") {
+		t.Errorf("expected the log destination to be restored")
+	}
+}
+
+func TestLogConfigurationConcurrentWithLogging(t *testing.T) {
+	var out logBuffer
+
+	m := New()
+	m.SetLogDestination(&out)
+
+	service := &loggingService{name: "svc"}
+	_ = m.Add(service).Wait(context.Background())
+
+	logger := service.logger
+	child := logger.WithGroup("request").With("id", 1)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			child.Info("probe")
+		}
+	}()
+
+	for i := 0; i < 10; i++ {
+		m.SetLogFormat(LogFormat(i % 2))
+		m.SetLogAttrs(LogAttrPhase)
+	}
+
+	<-done
+
+	m.SetLogFormat(LogFormatText)
+
+	if service.logger != logger {
+		t.Errorf("expected the logger to follow the configuration without being replaced")
+	}
+
+	out.Reset()
+	child.Info("probe")
+
+	if !strings.Contains(out.String(), "request.id=1") || !strings.Contains(out.String(), "phase=ready") {
+		t.Errorf("expected a derived logger to follow the configuration, got %q", out.String())
+	}
+}
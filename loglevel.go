@@ -0,0 +1,266 @@
+package servicemesh
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+	"sync"
+)
+
+// LogLevelEnv is the environment variable holding the log levels of a new
+// mesh, in the form accepted by SetLogLevels.
+const LogLevelEnv = "SERVICEMESH_LOG"
+
+// groupPrefix marks the log level of a group in the log level
+// specification accepted by SetLogLevels.
+const groupPrefix = "group:"
+
+// lowestLevel lets every record through a handler, so that the level can be
+// decided per service by a levelHandler.
+const lowestLevel = slog.Level(math.MinInt)
+
+// logLevels holds the log level overrides of a mesh, and the effective log
+// level of every service with a logger.
+type logLevels struct {
+	mu        sync.Mutex
+	services  map[string]slog.Level
+	groups    map[string]slog.Level
+	effective map[Service]*slog.LevelVar
+}
+
+// levelHandler is a slog.Handler which filters records by a log level which
+// may change at any time.
+type levelHandler struct {
+	next  slog.Handler
+	level slog.Leveler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.next.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{next: h.next.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{next: h.next.WithGroup(name), level: h.level}
+}
+
+// SetServiceLogLevel overrides the log level of the services with the given
+// name, in this mesh and its child meshes. The change applies immediately to
+// the existing loggers of the services.
+func (m *mesh) SetServiceLogLevel(name string, level slog.Level) {
+	m.levels.mu.Lock()
+	if m.levels.services == nil {
+		m.levels.services = make(map[string]slog.Level)
+	}
+	m.levels.services[name] = level
+	m.levels.mu.Unlock()
+
+	m.refreshLogLevels()
+
+	for _, child := range m.children() {
+		child.SetServiceLogLevel(name, level)
+	}
+}
+
+// SetGroupLogLevel overrides the log level of the members of the named
+// group, in this mesh and its child meshes. A log level set for a service by
+// name takes precedence over the log level of its groups, and a service in
+// several groups logs at the most verbose of their levels.
+func (m *mesh) SetGroupLogLevel(group string, level slog.Level) {
+	m.levels.mu.Lock()
+	if m.levels.groups == nil {
+		m.levels.groups = make(map[string]slog.Level)
+	}
+	m.levels.groups[group] = level
+	m.levels.mu.Unlock()
+
+	m.refreshLogLevels()
+
+	for _, child := range m.children() {
+		child.SetGroupLogLevel(group, level)
+	}
+}
+
+// SetLogLevels sets log levels from a comma-separated specification, such
+// as "info,db=debug,group:http=warn": a bare level sets the log level of the
+// mesh, "name=level" overrides the log level of a service, and
+// "group:name=level" overrides the log level of a group. Levels are parsed
+// as by slog.Level.UnmarshalText, eg. "debug", "WARN" or "info+2".
+//
+// A new mesh reads its log levels from the SERVICEMESH_LOG environment
+// variable in the same form.
+func (m *mesh) SetLogLevels(spec string) error {
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		target, text, found := strings.Cut(entry, "=")
+		if !found {
+			target, text = "", entry
+		}
+
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(text))); err != nil {
+			return fmt.Errorf("log level %q: %w", entry, err)
+		}
+
+		m.setLogLevelOf(strings.TrimSpace(target), level)
+	}
+
+	return nil
+}
+
+// setLogLevelOf sets the log level of a target of a log level specification.
+func (m *mesh) setLogLevelOf(target string, level slog.Level) {
+	switch {
+	case target == "":
+		m.SetLogLevel(level)
+	case strings.HasPrefix(target, groupPrefix):
+		m.SetGroupLogLevel(strings.TrimPrefix(target, groupPrefix), level)
+	default:
+		m.SetServiceLogLevel(target, level)
+	}
+}
+
+// applyLogLevelEnv sets the log levels given by the environment.
+func (m *mesh) applyLogLevelEnv() {
+	spec := os.Getenv(LogLevelEnv)
+	if spec == "" {
+		return
+	}
+
+	if err := m.SetLogLevels(spec); err != nil {
+		m.logger.Warn("ignoring invalid log levels", "env", LogLevelEnv, "error", err)
+	}
+}
+
+// levelFor yields the log level of a service, which stays up to date as log
+// levels are changed.
+func (m *mesh) levelFor(service Service) *slog.LevelVar {
+	m.levels.mu.Lock()
+
+	if m.levels.effective == nil {
+		m.levels.effective = make(map[Service]*slog.LevelVar)
+	}
+
+	level, found := m.levels.effective[service]
+	if !found {
+		level = new(slog.LevelVar)
+		m.levels.effective[service] = level
+	}
+
+	m.levels.mu.Unlock()
+
+	level.Set(m.effectiveLevel(service, m.groupsOf()))
+
+	return level
+}
+
+// refreshLogLevels updates the log level of every service.
+func (m *mesh) refreshLogLevels() {
+	groups := m.groupsOf()
+
+	m.levels.mu.Lock()
+	services := make([]Service, 0, len(m.levels.effective))
+	for service := range m.levels.effective {
+		services = append(services, service)
+	}
+	m.levels.mu.Unlock()
+
+	for _, service := range services {
+		m.levels.mu.Lock()
+		level := m.levels.effective[service]
+		m.levels.mu.Unlock()
+
+		if level != nil {
+			level.Set(m.effectiveLevel(service, groups))
+		}
+	}
+}
+
+// forgetLogLevel drops the log level of a service which left the mesh.
+func (m *mesh) forgetLogLevel(service Service) {
+	m.levels.mu.Lock()
+	defer m.levels.mu.Unlock()
+
+	delete(m.levels.effective, service)
+}
+
+// groupsOf maps every grouped service onto the names of its groups.
+func (m *mesh) groupsOf() map[Service][]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	groups := make(map[Service][]string)
+
+	for group, members := range m.groups {
+		for _, service := range members {
+			groups[service] = append(groups[service], group)
+		}
+	}
+
+	return groups
+}
+
+// effectiveLevel determines the log level of a service: its own override,
+// or else the most verbose override of its groups, or else the log level of
+// the mesh.
+func (m *mesh) effectiveLevel(service Service, groups map[Service][]string) slog.Level {
+	m.levels.mu.Lock()
+	defer m.levels.mu.Unlock()
+
+	if level, found := m.levels.services[service.Name()]; found {
+		return level
+	}
+
+	level, overridden := slog.Level(0), false
+
+	for _, group := range groups[service] {
+		if groupLevel, found := m.levels.groups[group]; found && (!overridden || groupLevel < level) {
+			level, overridden = groupLevel, true
+		}
+	}
+
+	if overridden {
+		return level
+	}
+
+	m.logMu.RLock()
+	defer m.logMu.RUnlock()
+
+	return m.logLevel
+}
+
+// copyLogLevels gives a child mesh the log level overrides of its parent.
+func (m *mesh) copyLogLevels(child *mesh) {
+	m.levels.mu.Lock()
+	defer m.levels.mu.Unlock()
+
+	for name, level := range m.levels.services {
+		if child.levels.services == nil {
+			child.levels.services = make(map[string]slog.Level)
+		}
+
+		child.levels.services[name] = level
+	}
+
+	for group, level := range m.levels.groups {
+		if child.levels.groups == nil {
+			child.levels.groups = make(map[string]slog.Level)
+		}
+
+		child.levels.groups[group] = level
+	}
+}
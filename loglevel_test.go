@@ -0,0 +1,134 @@
+package servicemesh
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logBuffer is a log destination which is safe for concurrent use.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func (b *logBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf.Reset()
+}
+
+type loggingService struct {
+	name   string
+	logger *slog.Logger
+}
+
+func (s *loggingService) Init(_ Mesh)                   {}
+func (s *loggingService) Name() string                  { return s.name }
+func (s *loggingService) SetLogger(logger *slog.Logger) { s.logger = logger }
+func (s *loggingService) Logger() *slog.Logger          { return s.logger }
+
+// logs reports whether a message logged by the service at the given level
+// reaches the log destination.
+func (s *loggingService) logs(out *logBuffer, level slog.Level) bool {
+	out.Reset()
+	s.logger.Log(context.Background(), level, "probe")
+
+	return strings.Contains(out.String(), "probe")
+}
+
+func TestServiceLogLevels(t *testing.T) {
+	var out logBuffer
+
+	m := New()
+	m.SetLogHandler(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	db := &loggingService{name: "db"}
+	web := &loggingService{name: "web"}
+
+	_ = m.AddAll(db, web).Wait(context.Background())
+
+	logger := db.logger
+
+	if db.logs(&out, slog.LevelDebug) {
+		t.Errorf("expected debug logs to be filtered by default")
+	}
+
+	m.SetServiceLogLevel("db", slog.LevelDebug)
+
+	if !db.logs(&out, slog.LevelDebug) || web.logs(&out, slog.LevelDebug) {
+		t.Errorf("expected only the overridden service to log at debug level")
+	}
+
+	if db.logger != logger {
+		t.Errorf("expected the level to change without replacing the logger")
+	}
+
+	m.AddToGroup("frontend", web)
+	m.SetGroupLogLevel("frontend", slog.LevelError)
+
+	if web.logs(&out, slog.LevelWarn) || !web.logs(&out, slog.LevelError) {
+		t.Errorf("expected the service to log at the level of its group")
+	}
+
+	// a service override takes precedence over the group
+	m.SetServiceLogLevel("web", slog.LevelInfo)
+
+	if !web.logs(&out, slog.LevelInfo) {
+		t.Errorf("expected the service override to take precedence over its group")
+	}
+
+	_ = Publish(m, LogLevelChange{Target: "db", Level: slog.LevelError}).Wait(context.Background())
+
+	if db.logs(&out, slog.LevelWarn) {
+		t.Errorf("expected the log level change event to apply")
+	}
+
+	if err := m.SetLogLevels("warn,db=debug,group:frontend=info"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !db.logs(&out, slog.LevelDebug) {
+		t.Errorf("expected the specification to override the service")
+	}
+
+	if err := m.SetLogLevels("db=loud"); err == nil {
+		t.Errorf("expected an invalid level to be reported")
+	}
+}
+
+func TestLogLevelEnv(t *testing.T) {
+	t.Setenv(LogLevelEnv, "error, db=debug")
+
+	var out logBuffer
+
+	m := New()
+	m.SetLogHandler(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	db := &loggingService{name: "db"}
+	web := &loggingService{name: "web"}
+
+	_ = m.AddAll(db, web).Wait(context.Background())
+
+	if !db.logs(&out, slog.LevelDebug) || web.logs(&out, slog.LevelWarn) {
+		t.Errorf("expected the log levels to be read from the environment")
+	}
+}
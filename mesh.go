@@ -1,6 +1,8 @@
 package servicemesh
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -26,96 +28,165 @@ func New(args ...string) Mesh {
 		name = strings.Join(args, " ")
 	}
 
-	r := &mesh{
-		name:      name,
-		events:    ee.New(),
-		logOutput: os.Stdout,
-		logLevel:  slog.LevelInfo,
-	}
+	r := newMesh(name)
 
 	// the service mesh itself is a service
 	// that binds handlers to its own events
 	r.Add(r)
 
+	r.applyLogLevelEnv()
+
 	return r
 }
 
+// newMesh creates a bare mesh instance. The caller is responsible for adding
+// the mesh to itself once any parent relationship has been established.
+func newMesh(name string) *mesh {
+	return &mesh{
+		name:             name,
+		events:           ee.New(),
+		logOutput:        os.Stdout,
+		logLevel:         slog.LevelInfo,
+		historyRetention: defaultEventHistoryRetention,
+		shutdownTimeout:  defaultShutdownTimeout,
+		lazyInitTimeout:  defaultLazyInitTimeout,
+	}
+}
+
 var _ Mesh = &mesh{}
 
 // mesh represents a collection of service mesh services.
 type mesh struct {
-	name         string
-	quit         chan os.Signal
-	services     []Service
-	logger       *slog.Logger
-	logOutput    io.Writer
-	logLevel     slog.Level
-	logHandler   slog.Handler
-	events       *ee.EventEmitter
-	shuttingDown bool
+	mu               sync.RWMutex
+	initOnce         sync.Once
+	name             string
+	parent           *mesh
+	bubbleEvents     bool
+	quit             chan os.Signal
+	services         []Service
+	groups           map[string][]Service
+	lazy             map[Service]*lazyService
+	factories        []*factory
+	scoped           map[*factory]*factoryInstance
+	detached         []any
+	ready            map[Service]bool
+	replicaSets      map[string]*replicaSet
+	replicaOf        map[Service]*replicaSet
+	logger           *slog.Logger
+	logOutput        io.Writer
+	logLevel         slog.Level
+	logHandler       slog.Handler
+	logFormat        LogFormat
+	logWrappers      []LogHandlerWrapper
+	logAttrs         LogAttr
+	logContexts      logContexts
+	recentLogs       *recentLogs
+	logFiles         *logFiles
+	logMu            sync.RWMutex
+	levels           logLevels
+	events           *ee.EventEmitter
+	bus              bus
+	historyRetention int
+	shutdownTimeout  time.Duration
+	lazyInitTimeout  time.Duration
+	ids              map[Service]string
+	bindings         map[Service][]Unsubscribe
+	responders       map[string]bool
+	shuttingDown     bool
 }
 
 func (m *mesh) Init(_ Mesh) {
-	if m.services != nil {
-		return
-	}
+	// a child mesh is initialized as a service of its own, concurrently with
+	// the services being added to it
+	m.initOnce.Do(m.init)
+}
 
+func (m *mesh) init() {
 	m.logger = m.newLogger(m)
 	m.services = make([]Service, 0)
 	m.quit = make(chan os.Signal, 1)
 
 	m.logger.Debug("initializing")
-	signal.Notify(m.quit, os.Interrupt)
+
+	// only the root mesh listens for OS signals, child meshes are shut down
+	// by their parent
+	if m.parent == nil {
+		signal.Notify(m.quit, os.Interrupt)
+	}
 }
 
-// Add a single service to the mesh.
-func (m *mesh) Add(service Service) *sync.WaitGroup {
+// Add a single service to the mesh. The returned Operation completes once the
+// dependencies of the service have been resolved and it has been initialized.
+func (m *mesh) Add(service Service) *Operation {
 	m.Init(nil) // always ensure service mesh is init
 
+	op := newOperation()
+
+	m.register(service)
+
+	// Check if the service is a HasDependencies
+	if resolver, ok := service.(HasDependencies); ok {
+		// Resolve dependencies before initialization
+		go func() {
+			m.resolveDependencies(resolver)
+			op.complete(m.safeInitService(service))
+		}()
+	} else {
+		// No dependencies to resolve, directly initialize the service
+		go func() {
+			op.complete(m.safeInitService(service))
+		}()
+	}
+
+	return op
+}
+
+// register makes a service known to the mesh without initializing it. The
+// logger of the service is bound, the service is tagged into its groups, and
+// its event handlers are bound to the event bus.
+func (m *mesh) register(service Service) {
 	defer func() {
 		m.bindEventHandlerInterfaces(service)
 	}()
 
-	var wg sync.WaitGroup
-
 	if service != m {
 		m.logger.Debug("preparing service", "service", service.Name())
 	}
 
+	m.assignID(service)
+	m.setPhase(service, phaseAdded)
+
 	// Check if the service uses a logger
 	if candidate, ok := service.(HasLogger); ok {
-		wg.Add(1)
 		candidate.SetLogger(m.newLogger(service))
-		m.events.Emit(EventServiceLoggerBound, service).Wait()
-		wg.Done()
+		m.emit(EventServiceLoggerBound, service)
 	}
 
+	m.mu.Lock()
 	m.services = append(m.services, service)
-	m.events.Emit(EventServiceAdded, service)
+	m.mu.Unlock()
 
-	// Check if the service is a HasDependencies
-	if resolver, ok := service.(HasDependencies); ok {
-		// Resolve dependencies before initialization
-		wg.Add(1)
-		go func() {
-			m.resolveDependenciesAndInit(resolver)
-			wg.Done()
-		}()
-	} else {
-		// No dependencies to resolve, directly initialize the service
-		wg.Add(1)
-		go func() {
-			m.initService(service)
-			wg.Done()
-		}()
-	}
+	m.bindGroups(service)
+
+	m.emit(EventServiceAdded, service)
+}
 
-	return &wg
+// resolveDependencies blocks until the dependencies of the service have been
+// resolved.
+func (m *mesh) resolveDependencies(resolver HasDependencies) {
+	m.beginResolution(resolver)
+	m.finishResolution(resolver)
 }
 
-func (m *mesh) resolveDependenciesAndInit(resolver HasDependencies) {
-	m.events.Emit(EventDependencyResolutionStarted, resolver)
+// beginResolution marks the start of the dependency resolution of a service.
+func (m *mesh) beginResolution(resolver HasDependencies) {
+	m.setPhase(resolver, phaseResolving)
+	m.emit(EventDependencyResolutionStarted, resolver)
+}
 
+// finishResolution blocks until the dependencies of a service whose
+// resolution has begun have been resolved.
+func (m *mesh) finishResolution(resolver HasDependencies) {
 	go func() {
 		for !resolver.DependenciesResolved() {
 			m.logger.Debug("dependencies not resolved", "service", resolver.Name())
@@ -123,19 +194,27 @@ func (m *mesh) resolveDependenciesAndInit(resolver HasDependencies) {
 		}
 	}()
 
-	// Check if all dependencies are resolved
+	m.awaitDependencies(resolver)
+
+	m.emit(EventDependencyResolutionEnded, resolver)
+}
+
+// awaitDependencies hands the services of the mesh to a service until it
+// reports its dependencies as resolved.
+func (m *mesh) awaitDependencies(resolver HasDependencies) {
 	for !resolver.DependenciesResolved() {
-		resolver.ResolveDependencies(m.Services())
+		if m.offerDependencies(resolver, m.resolvableServices()) {
+			return
+		}
+
 		time.Sleep(dependencyResolutionDwellDuration)
 	}
-
-	m.events.Emit(EventDependencyResolutionEnded, resolver)
-
-	m.initService(resolver)
 }
 
 // initService initializes a service after being added to the mesh.
 func (m *mesh) initService(service Service) {
+	m.setPhase(service, phaseInitializing)
+
 	if l, ok := service.(HasLogger); ok && l.Logger() != nil {
 		l.Logger().Debug("initializing")
 	} else {
@@ -144,34 +223,75 @@ func (m *mesh) initService(service Service) {
 
 	service.Init(m)
 
-	m.events.Emit(EventServiceInitialized, service)
+	m.mu.Lock()
+	if m.ready == nil {
+		m.ready = make(map[Service]bool)
+	}
+	m.ready[service] = true
+	m.mu.Unlock()
+
+	m.setPhase(service, phaseReady)
+	m.emit(EventServiceInitialized, service)
+}
+
+// isReady returns true if the service has been initialized.
+func (m *mesh) isReady(service Service) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.ready[service]
 }
 
 // Services returns a pointer to a slice of Services managed by the mesh.
 func (m *mesh) Services() (list []Service) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	return append(list, m.services...)
 }
 
-// Remove a specific service from the mesh.
-func (m *mesh) Remove(service Service) *sync.WaitGroup {
-	wg := m.events.Emit(EventServiceRemoved)
+// Remove a specific service from the mesh. The returned Operation completes
+// once the event handlers for the removal have finished, and reports
+// ErrServiceNotFound if the service was not present in the mesh.
+func (m *mesh) Remove(service Service) *Operation {
+	m.mu.Lock()
+
+	removed := false
 
 	for i, svc := range m.services {
 		if svc == service {
 			m.logger.Debug("removing service", "service", service.Name())
 			m.services = append(m.services[:i], m.services[i+1:]...)
+			delete(m.ready, service)
+			removed = true
 			break
 		}
 	}
 
-	return wg
+	m.mu.Unlock()
+
+	if !removed {
+		return completedOperation(fmt.Errorf("%w: %s", ErrServiceNotFound, service.Name()))
+	}
+
+	m.forgetLazy(service)
+	m.forgetReplica(service)
+	m.unbindEventHandlers(service)
+	m.forgetLogLevel(service)
+	m.setPhase(service, phaseRemoved)
+	m.forgetLogContext(service)
+
+	return operationFromWaitGroup(m.emit(EventServiceRemoved, service))
 }
 
 // Shutdown sends an interrupt signal to the mesh, indicating it should exit.
-func (m *mesh) Shutdown() *sync.WaitGroup {
+// The returned Operation completes once every service has been shut down and
+// the shutdown event handlers have finished. A panic within the OnShutdown
+// method of a service is reported as an error.
+func (m *mesh) Shutdown() *Operation {
 	if m.shuttingDown {
 		// if we are already shutting down, nothing to do
-		return &sync.WaitGroup{}
+		return completedOperation(nil)
 	}
 
 	// if this method has been invoked, send SIGINT to unblock the Run method
@@ -179,9 +299,15 @@ func (m *mesh) Shutdown() *sync.WaitGroup {
 	m.quit <- syscall.SIGINT
 
 	// we will give all shutdown event handlers a chance to respond
-	wg := m.events.Emit(EventServiceMeshShutdownInitiated)
+	wg := m.emit(EventServiceMeshShutdownInitiated)
+
+	var errs []error
+
+	for _, service := range m.Services() {
+		if service == m {
+			continue
+		}
 
-	for _, service := range m.services {
 		if quitter, ok := service.(HasGracefulShutdown); ok {
 
 			if l, ok := quitter.(HasLogger); ok && l.Logger() != nil {
@@ -190,14 +316,42 @@ func (m *mesh) Shutdown() *sync.WaitGroup {
 				m.logger.Debug("shutting down service", "service", service.Name())
 			}
 
-			quitter.OnShutdown()
+			if err := m.safeShutdownService(quitter); err != nil {
+				errs = append(errs, err)
+			}
 		}
 	}
 
+	if err := m.shutdownDetached(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := m.drainEvents(); err != nil {
+		errs = append(errs, err)
+	}
+
 	m.logger.Warn("exiting")
+	m.closeLogFiles()
 
 	// allow the caller to wait for the event handlers to finish
-	return wg
+	return joinOperations(operationFromWaitGroup(wg), completedOperation(errors.Join(errs...)))
+}
+
+// safeShutdownService invokes the OnShutdown method of a service, recovering
+// from a panic and reporting it as an error.
+func (m *mesh) safeShutdownService(service HasGracefulShutdown) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Error("service shutdown panicked", "service", service.Name(), "panic", r)
+			err = fmt.Errorf("%w: %s: %v", ErrShutdownPanicked, service.Name(), r)
+		}
+	}()
+
+	m.setPhase(service, phaseShuttingDown)
+
+	service.OnShutdown()
+
+	return nil
 }
 
 // Name returns the name of the mesh.
@@ -209,17 +363,25 @@ func (m *mesh) Ready() bool { return true }
 
 // Run starts the mesh and waits for an interrupt signal to exit.
 func (m *mesh) Run() {
-	m.events.Emit(EventServiceMeshRunLoopInitiated)
+	m.emit(EventServiceMeshRunLoopInitiated)
 
 	<-m.quit              // blocks until signal is recieved
 	fmt.Printf("\033[2D") // Remove ^C from stdout
 
-	m.Shutdown().Wait()
+	_ = m.Shutdown().Wait(context.Background())
 	time.Sleep(time.Second)
 }
 
-// Events yields the global event bus for the service mesh
-func (m *mesh) Events() *ee.EventEmitter {
+// Events yields the global event bus for the service mesh. Events emitted
+// with it pass through the event bus of the mesh like those emitted with Emit,
+// so they reach the subscribers, middleware, history, and bridges as well.
+func (m *mesh) Events() EventEmitter {
+	return meshEmitter{m.rawEvents(), m}
+}
+
+// rawEvents yields the raw event emitter, which holds the listeners bound to
+// Events.
+func (m *mesh) rawEvents() *ee.EventEmitter {
 	if m.events == nil {
 		m.events = ee.New()
 	}
@@ -227,134 +389,268 @@ func (m *mesh) Events() *ee.EventEmitter {
 	return m.events
 }
 
+// meshEmitter is the event emitter yielded by Events. Listeners are bound to
+// the raw event emitter, while events are emitted on the event bus.
+type meshEmitter struct {
+	*ee.EventEmitter
+	mesh *mesh
+}
+
+// Emit emits an event on the event bus of the mesh, see mesh.Emit.
+func (e meshEmitter) Emit(event string, args ...any) *sync.WaitGroup {
+	args, env := e.mesh.userEnvelope(args)
+
+	return e.mesh.dispatch(event, args, env)
+}
+
+// emit publishes a built-in event on the event bus of the mesh. If the mesh is
+// a child mesh with event bubbling enabled, the event is also emitted on the
+// bus of the parent mesh.
+//
+// Events are delivered to the subscribers of the mesh bus, and are then
+// forwarded to the raw event emitter yielded by Events().
+func (m *mesh) emit(event string, args ...any) *sync.WaitGroup {
+	return m.dispatch(event, args, m.envelopeFor(args))
+}
+
+// trackBinding remembers a subscription made on behalf of a service, so that
+// it can be removed when the service is removed from the mesh.
+func (m *mesh) trackBinding(service Service, unsubscribe Unsubscribe) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.bindings == nil {
+		m.bindings = make(map[Service][]Unsubscribe)
+	}
+
+	m.bindings[service] = append(m.bindings[service], unsubscribe)
+}
+
+func (m *mesh) hasBindings(service Service) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.bindings[service]) > 0
+}
+
+// unbindEventHandlers removes every subscription which was made on behalf of
+// a service by bindEventHandlerInterfaces.
+func (m *mesh) unbindEventHandlers(service Service) {
+	m.mu.Lock()
+	list := m.bindings[service]
+	delete(m.bindings, service)
+	m.mu.Unlock()
+
+	for _, unsubscribe := range list {
+		unsubscribe()
+	}
+}
+
 // bindEventHandlerInterfaces provides the syntactic sugar for services that
 // want to bind event handlers to the event bus for specific service mesh
-// events. These are just wrappers for binding callbacks with the event emitter.
-// This allows other services to implement the event bus intergation interfaces
-// without needing to know how to use the event emitter.
+// events. These are just wrappers for subscribing to the typed events of the
+// mesh. Services implementing ReplaysEventHistory have the event history of
+// the mesh replayed to each of their event handlers. Services implementing
+// HasEventHandlers additionally have their custom event handlers bound.
+//
+// The bindings are removed again when the service is removed from the mesh.
+// This allows other services to implement the event bus integration
+// interfaces without needing to know how to use the event bus.
 func (m *mesh) bindEventHandlerInterfaces(service Service) {
+	// child meshes handle their own events, binding them to the parent bus
+	// would duplicate everything they log
+	if child, ok := service.(*mesh); ok && child != m {
+		return
+	}
+
+	opts := append(replayOptions(service), WithName(service.Name()))
+
 	if handler, ok := service.(EventHandlerServiceAdded); ok {
 		if service != m {
 			m.logger.Debug("bound 'EventServiceAdded' event handler", "service", service.Name())
 		}
-
-		m.Events().On(EventServiceAdded, func(args ...any) {
-			if len(args) < 1 {
-				return
-			}
-
-			if serviceArg, ok := args[0].(Service); ok {
-				handler.OnServiceAdded(serviceArg)
-			}
-		})
+		m.trackBinding(service, Subscribe(m, func(e ServiceAdded) {
+			handler.OnServiceAdded(e.Service)
+		}, opts...))
 	}
 
 	if handler, ok := service.(EventHandlerServiceRemoved); ok {
 		if service != m {
 			m.logger.Debug("bound 'EventServiceRemoved' event handler", "service", service.Name())
 		}
-		m.Events().On(EventServiceRemoved, func(args ...any) {
-			if len(args) < 1 {
-				return
-			}
-
-			if serviceArg, ok := args[0].(Service); ok {
-				handler.OnServiceRemoved(serviceArg)
-			}
-		})
+		m.trackBinding(service, Subscribe(m, func(e ServiceRemoved) {
+			handler.OnServiceRemoved(e.Service)
+		}, opts...))
 	}
 
 	if handler, ok := service.(EventHandlerServiceInitialized); ok {
 		if service != m {
 			m.logger.Debug("bound 'EventServiceInitialized' event handler", "service", service.Name())
 		}
-		m.Events().On(EventServiceInitialized, func(args ...any) {
-			if len(args) < 1 {
-				return
-			}
-
-			if serviceArg, ok := args[0].(Service); ok {
-				handler.OnServiceInitialized(serviceArg)
-			}
-		})
+		m.trackBinding(service, Subscribe(m, func(e ServiceInitialized) {
+			handler.OnServiceInitialized(e.Service)
+		}, opts...))
 	}
 
 	if handler, ok := service.(EventHandlerServiceEventsBound); ok {
 		if service != m {
 			m.logger.Debug("bound 'EventServiceEventsBound' event handler", "service", service.Name())
 		}
-		m.Events().On(EventServiceEventsBound, func(args ...any) {
-			if len(args) < 1 {
-				return
-			}
-
-			if serviceArg, ok := args[0].(Service); ok {
-				handler.OnServiceEventsBound(serviceArg)
-			}
-		})
+		m.trackBinding(service, Subscribe(m, func(e ServiceEventsBound) {
+			handler.OnServiceEventsBound(e.Service)
+		}, opts...))
 	}
 
 	if handler, ok := service.(EventHandlerServiceLoggerBound); ok {
 		if service != m {
 			m.logger.Debug("bound 'EventServiceLoggerBound' event handler", "service", service.Name())
 		}
-		m.Events().On(EventServiceLoggerBound, func(args ...any) {
-			if len(args) < 1 {
-				return
-			}
+		m.trackBinding(service, Subscribe(m, func(e ServiceLoggerBound) {
+			handler.OnServiceLoggerBound(e.Service)
+		}, opts...))
+	}
 
-			if serviceArg, ok := args[0].(Service); ok {
-				handler.OnServiceLoggerBound(serviceArg)
-			}
-		})
+	if handler, ok := service.(EventHandlerServiceInitDeferred); ok {
+		if service != m {
+			m.logger.Debug("bound 'EventServiceInitDeferred' event handler", "service", service.Name())
+		}
+		m.trackBinding(service, Subscribe(m, func(e ServiceInitDeferred) {
+			handler.OnServiceInitDeferred(e.Service)
+		}, opts...))
+	}
+
+	if handler, ok := service.(EventHandlerServiceDeferredInitStarted); ok {
+		if service != m {
+			m.logger.Debug("bound 'EventServiceDeferredInitStarted' event handler", "service", service.Name())
+		}
+		m.trackBinding(service, Subscribe(m, func(e ServiceDeferredInitStarted) {
+			handler.OnServiceDeferredInitStarted(e.Service)
+		}, opts...))
+	}
+
+	if handler, ok := service.(EventHandlerGroupStarted); ok {
+		if service != m {
+			m.logger.Debug("bound 'EventGroupStarted' event handler", "service", service.Name())
+		}
+		m.trackBinding(service, Subscribe(m, func(e GroupStarted) {
+			handler.OnGroupStarted(e.Group)
+		}, opts...))
+	}
+
+	if handler, ok := service.(EventHandlerGroupStopped); ok {
+		if service != m {
+			m.logger.Debug("bound 'EventGroupStopped' event handler", "service", service.Name())
+		}
+		m.trackBinding(service, Subscribe(m, func(e GroupStopped) {
+			handler.OnGroupStopped(e.Group)
+		}, opts...))
+	}
+
+	if handler, ok := service.(EventHandlerGroupRemoved); ok {
+		if service != m {
+			m.logger.Debug("bound 'EventGroupRemoved' event handler", "service", service.Name())
+		}
+		m.trackBinding(service, Subscribe(m, func(e GroupRemoved) {
+			handler.OnGroupRemoved(e.Group)
+		}, opts...))
+	}
+
+	if handler, ok := service.(EventHandlerReplicasScaled); ok {
+		if service != m {
+			m.logger.Debug("bound 'EventReplicasScaled' event handler", "service", service.Name())
+		}
+		m.trackBinding(service, Subscribe(m, func(e ReplicasScaled) {
+			handler.OnReplicasScaled(e.Name, e.Replicas)
+		}, opts...))
+	}
+
+	if handler, ok := service.(EventHandlerRemoteServiceAdded); ok {
+		if service != m {
+			m.logger.Debug("bound 'EventRemoteServiceAdded' event handler", "service", service.Name())
+		}
+		m.trackBinding(service, Subscribe(m, func(e RemoteServiceAdded) {
+			handler.OnRemoteServiceAdded(e.Service)
+		}, opts...))
+	}
+
+	if handler, ok := service.(EventHandlerRemoteServiceRemoved); ok {
+		if service != m {
+			m.logger.Debug("bound 'EventRemoteServiceRemoved' event handler", "service", service.Name())
+		}
+		m.trackBinding(service, Subscribe(m, func(e RemoteServiceRemoved) {
+			handler.OnRemoteServiceRemoved(e.Service)
+		}, opts...))
+	}
+
+	if handler, ok := service.(EventHandlerLogLevelChange); ok {
+		if service != m {
+			m.logger.Debug("bound 'EventLogLevelChange' event handler", "service", service.Name())
+		}
+		m.trackBinding(service, Subscribe(m, func(e LogLevelChange) {
+			handler.OnLogLevelChange(e.Target, e.Level)
+		}, opts...))
+	}
+
+	if handler, ok := service.(EventHandlerHandlerFailed); ok {
+		if service != m {
+			m.logger.Debug("bound 'EventHandlerFailed' event handler", "service", service.Name())
+		}
+		m.trackBinding(service, Subscribe(m, func(e HandlerFailed) {
+			handler.OnHandlerFailed(e.Event, e.Handler, e.Err, e.Attempts)
+		}, opts...))
 	}
 
 	if handler, ok := service.(EventHandlerServiceMeshRunLoopInitiated); ok {
 		if service != m {
 			m.logger.Debug("bound 'EventServiceMeshRunLoopInitiated' event handler", "service", service.Name())
 		}
-		m.Events().On(EventServiceMeshRunLoopInitiated, func(_ ...any) {
+		m.trackBinding(service, Subscribe(m, func(_ ServiceMeshRunLoopInitiated) {
 			handler.OnServiceMeshRunLoopInitiated()
-		})
+		}, opts...))
 	}
 
 	if handler, ok := service.(EventHandlerServiceMeshShutdownInitiated); ok {
 		if service != m {
 			m.logger.Debug("bound 'EventServiceMeshShutdownInitiated' event handler", "service", service.Name())
 		}
-		m.Events().On(EventServiceMeshShutdownInitiated, func(_ ...any) {
+		m.trackBinding(service, Subscribe(m, func(_ ServiceMeshShutdownInitiated) {
 			handler.OnServiceMeshShutdownInitiated()
-		})
+		}, opts...))
 	}
 
 	if handler, ok := service.(EventHandlerDependencyResolutionStarted); ok {
 		if service != m {
 			m.logger.Debug("bound 'EventDependencyResolutionStarted' event handler", "service", service.Name())
 		}
-		m.Events().On(EventDependencyResolutionStarted, func(args ...any) {
-			if len(args) < 1 {
-				return
-			}
-
-			if serviceArg, ok := args[0].(Service); ok {
-				handler.OnDependencyResolutionStarted(serviceArg)
-			}
-		})
+		m.trackBinding(service, Subscribe(m, func(e DependencyResolutionStarted) {
+			handler.OnDependencyResolutionStarted(e.Service)
+		}, opts...))
 	}
 
 	if handler, ok := service.(EventHandlerDependencyResolutionEnded); ok {
 		if service != m {
 			m.logger.Debug("bound 'EventDependencyResolutionEnded' event handler", "service", service.Name())
 		}
-		m.Events().On(EventDependencyResolutionEnded, func(args ...any) {
-			if len(args) < 1 {
-				return
-			}
+		m.trackBinding(service, Subscribe(m, func(e DependencyResolutionEnded) {
+			handler.OnDependencyResolutionEnded(e.Service)
+		}, opts...))
+	}
 
-			if serviceArg, ok := args[0].(Service); ok {
-				handler.OnDependencyResolutionEnded(serviceArg)
+	if handler, ok := service.(HasEventHandlers); ok {
+		for event, fn := range handler.EventHandlers() {
+			if service != m {
+				m.logger.Debug("bound custom event handler", "service", service.Name(), "event", event)
 			}
-		})
+
+			fn := fn // keep in scope
+			m.trackBinding(service, m.subscribe(event, func(e Event) {
+				fn(e.Args...)
+			}, opts...))
+		}
+	}
+
+	if m.hasBindings(service) {
+		m.emit(EventServiceEventsBound, service)
 	}
 }
 
@@ -411,3 +707,44 @@ func (m *mesh) OnDependencyResolutionEnded(service Service) {
 		m.logger.Debug("dependency resolution completed", "service", service.Name())
 	}
 }
+
+func (m *mesh) OnServiceInitDeferred(service Service) {
+	m.logger.Debug("service init deferred", "service", service.Name())
+}
+
+func (m *mesh) OnServiceDeferredInitStarted(service Service) {
+	m.logger.Debug("deferred service init started", "service", service.Name())
+}
+
+func (m *mesh) OnReplicasScaled(name string, replicas int) {
+	m.logger.Debug("replicas scaled", "replicas", name, "count", replicas)
+}
+
+func (m *mesh) OnRemoteServiceAdded(service Service) {
+	m.logger.Info("remote service added", "service", service.Name(), "remote", remoteMeshOf(service))
+}
+
+func (m *mesh) OnRemoteServiceRemoved(service Service) {
+	m.logger.Info("remote service removed", "service", service.Name(), "remote", remoteMeshOf(service))
+}
+
+// OnLogLevelChange applies a log level change requested over the event bus.
+func (m *mesh) OnLogLevelChange(target string, level slog.Level) {
+	m.setLogLevelOf(target, level)
+}
+
+func (m *mesh) OnHandlerFailed(event, handler string, err error, attempts int) {
+	m.logger.Error("event handler failed", "event", event, "handler", handler, "error", err, "attempts", attempts)
+}
+
+func (m *mesh) OnGroupStarted(group string) {
+	m.logger.Debug("group started", "group", group)
+}
+
+func (m *mesh) OnGroupStopped(group string) {
+	m.logger.Debug("group stopped", "group", group)
+}
+
+func (m *mesh) OnGroupRemoved(group string) {
+	m.logger.Debug("group removed", "group", group)
+}
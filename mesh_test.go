@@ -1,6 +1,7 @@
 package servicemesh
 
 import (
+	"context"
 	"log/slog"
 	"testing"
 	"time"
@@ -12,7 +13,7 @@ func TestRuntime(t *testing.T) {
 
 	go func() {
 		time.Sleep(time.Second * 3)
-		m.Shutdown().Wait()
+		m.Shutdown().Wait(context.Background())
 	}()
 
 	m.Add(s)
@@ -0,0 +1,46 @@
+package servicemesh
+
+// EventHandlerFunc handles a single event passing through the event bus of
+// the mesh.
+type EventHandlerFunc func(e Event)
+
+// EventMiddleware wraps the handling of events on the event bus of the mesh.
+// A middleware may inspect or alter an event before passing it on to next,
+// measure how long next takes, or drop the event by not calling next at all.
+// A middleware must call next synchronously.
+type EventMiddleware func(next EventHandlerFunc) EventHandlerFunc
+
+// UseEventMiddleware adds middleware to the event bus of the mesh. The
+// middleware is applied in the order it was added, the first being the
+// outermost.
+//
+// Middleware is applied on both the publishing and the delivery path of
+// every event, built-in and user events alike, including those emitted with
+// the raw event emitter yielded by Events:
+//
+//   - When an event is published, the middleware wraps the fan-out of the
+//     event to all subscribers and to the raw event emitter. At this point,
+//     the Handler of the event is empty. Altering the event here alters it
+//     for every subscriber, and dropping it here drops it entirely.
+//   - When an event is delivered to a subscriber, the middleware wraps the
+//     handler of the subscriber. At this point, the Handler of the event
+//     names the subscriber.
+func (m *mesh) UseEventMiddleware(middleware ...EventMiddleware) {
+	m.bus.mu.Lock()
+	defer m.bus.mu.Unlock()
+
+	m.bus.middleware = append(m.bus.middleware, middleware...)
+}
+
+// withMiddleware wraps a handler with the event middleware of the mesh.
+func (m *mesh) withMiddleware(handler EventHandlerFunc) EventHandlerFunc {
+	m.bus.mu.RLock()
+	list := m.bus.middleware
+	m.bus.mu.RUnlock()
+
+	for i := len(list) - 1; i >= 0; i-- {
+		handler = list[i](handler)
+	}
+
+	return handler
+}
@@ -0,0 +1,112 @@
+package servicemesh
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestEventMiddleware(t *testing.T) {
+	m := New()
+
+	var mu sync.Mutex
+	var published, delivered []string
+
+	m.UseEventMiddleware(func(next EventHandlerFunc) EventHandlerFunc {
+		return func(e Event) {
+			if e.Name == "secret" {
+				return // drop
+			}
+
+			mu.Lock()
+			if e.Handler == "" {
+				published = append(published, e.Name)
+			} else {
+				delivered = append(delivered, e.Handler)
+			}
+			mu.Unlock()
+
+			next(e)
+		}
+	}, func(next EventHandlerFunc) EventHandlerFunc {
+		return func(e Event) {
+			if e.Name == "greeting" && e.Handler == "" {
+				e.Args = []any{"redacted"}
+			}
+
+			next(e)
+		}
+	})
+
+	received := make(chan any, 10)
+	SubscribePattern(m, "greeting", func(e Event) {
+		received <- e.Args[0]
+	}, WithName("greeter"))
+
+	SubscribePattern(m, "secret", func(e Event) {
+		received <- e.Args[0]
+	})
+
+	_ = m.Emit("secret", "password").Wait(context.Background())
+	_ = m.Emit("greeting", "hello").Wait(context.Background())
+
+	if got := <-received; got != "redacted" {
+		t.Errorf("expected the publishing middleware to alter the event, got %v", got)
+	}
+
+	select {
+	case got := <-received:
+		t.Errorf("expected the dropped event not to be delivered, got %v", got)
+	default:
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !containsString(published, "greeting") || containsString(published, "secret") {
+		t.Errorf("unexpected published events: %v", published)
+	}
+
+	if !containsString(delivered, "greeter") {
+		t.Errorf("expected the delivery to the named subscriber, got %v", delivered)
+	}
+}
+
+func TestEventMiddlewareRawEvents(t *testing.T) {
+	m := New()
+
+	m.UseEventMiddleware(func(next EventHandlerFunc) EventHandlerFunc {
+		return func(e Event) {
+			if e.Name != "secret" {
+				next(e)
+			}
+		}
+	})
+
+	received := make(chan any, 10)
+	m.Events().On("secret", func(args ...any) { received <- args[0] })
+	m.Events().On("greeting", func(args ...any) { received <- args[0] })
+
+	m.Events().Emit("secret", "password").Wait()
+	m.Events().Emit("greeting", "hello").Wait()
+
+	if got := <-received; got != "hello" {
+		t.Errorf("expected the event emitted with Events to pass the middleware, got %v", got)
+	}
+
+	select {
+	case got := <-received:
+		t.Errorf("expected the dropped event not to be emitted, got %v", got)
+	default:
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, candidate := range list {
+		if candidate == s {
+			return true
+		}
+	}
+
+	return false
+}
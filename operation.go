@@ -0,0 +1,115 @@
+package servicemesh
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Operation is a handle for an asynchronous lifecycle operation of the mesh,
+// such as adding, removing, or shutting down services.
+//
+// Unlike a sync.WaitGroup, an Operation can carry an error, and it can be
+// waited upon with a deadline or cancellation through a context.
+type Operation struct {
+	done chan struct{}
+	once sync.Once
+	mu   sync.Mutex
+	err  error
+}
+
+func newOperation() *Operation {
+	return &Operation{done: make(chan struct{})}
+}
+
+// completedOperation yields an Operation which has already completed with
+// the given error.
+func completedOperation(err error) *Operation {
+	op := newOperation()
+	op.complete(err)
+
+	return op
+}
+
+// Done returns a channel which is closed when the operation completes.
+func (o *Operation) Done() <-chan struct{} {
+	return o.done
+}
+
+// Err returns the error the operation completed with. It returns nil while
+// the operation is still in progress.
+func (o *Operation) Err() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.err
+}
+
+// Wait blocks until the operation completes or the context is done. It
+// returns the error of the operation, or the error of the context if the
+// context was done first.
+func (o *Operation) Wait(ctx context.Context) error {
+	select {
+	case <-o.done:
+		return o.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitGroup adapts the operation to a sync.WaitGroup, for callers which
+// were written against the earlier lifecycle API.
+func (o *Operation) WaitGroup() *sync.WaitGroup {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		<-o.done
+		wg.Done()
+	}()
+
+	return &wg
+}
+
+func (o *Operation) complete(err error) {
+	o.once.Do(func() {
+		o.mu.Lock()
+		o.err = err
+		o.mu.Unlock()
+
+		close(o.done)
+	})
+}
+
+// operationFromWaitGroup yields an Operation which completes without error
+// when the given WaitGroup completes.
+func operationFromWaitGroup(wg *sync.WaitGroup) *Operation {
+	op := newOperation()
+
+	go func() {
+		wg.Wait()
+		op.complete(nil)
+	}()
+
+	return op
+}
+
+// joinOperations yields a single Operation which completes once all of the
+// given operations have completed, with all of their errors joined.
+func joinOperations(list ...*Operation) *Operation {
+	op := newOperation()
+
+	go func() {
+		errs := make([]error, 0, len(list))
+
+		for _, pending := range list {
+			<-pending.Done()
+			errs = append(errs, pending.Err())
+		}
+
+		op.complete(errors.Join(errs...))
+	}()
+
+	return op
+}
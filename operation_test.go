@@ -0,0 +1,42 @@
+package servicemesh
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOperation(t *testing.T) {
+	m := New()
+
+	if err := m.Add(&providerService{}).Wait(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err := m.Add(&panickingService{}).Wait(context.Background())
+	if !errors.Is(err, ErrInitPanicked) {
+		t.Errorf("expected init panic to be reported, got %v", err)
+	}
+
+	err = m.Remove(&groupedService{name: "missing"}).Wait(context.Background())
+	if !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("expected missing service to be reported, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	err = m.Add(&orphanService{}).Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline to be exceeded, got %v", err)
+	}
+
+	m.Add(&providerService{}).WaitGroup().Wait()
+}
+
+type panickingService struct{}
+
+func (p *panickingService) Init(_ Mesh) { panic("boom") }
+
+func (p *panickingService) Name() string { return "panicking" }
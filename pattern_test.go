@@ -0,0 +1,122 @@
+package servicemesh
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ee "github.com/gravestench/eventemitter"
+)
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*", "service added", true},
+		{"*", "", true},
+		{"service *", "service added", true},
+		{"service *", "group started", false},
+		{"orders.*", "orders.created", true},
+		{"orders.*", "orders", false},
+		{"orders.*.eu", "orders.created.eu", true},
+		{"orders.*.eu", "orders.created.us", false},
+		{"group ?topped", "group stopped", true},
+		{"*bound", EventServiceLoggerBound, true},
+		{"service added", "service added", true},
+		{"service added", "service removed", false},
+	}
+
+	for _, c := range cases {
+		if got := matchPattern(c.pattern, c.name); got != c.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", c.pattern, c.name, got, c.want)
+		}
+	}
+}
+
+func TestSubscribePattern(t *testing.T) {
+	m := New()
+
+	received := make(chan string, 100)
+	unsubscribe := SubscribePattern(m, "orders.*", func(e Event) {
+		received <- e.Name
+	})
+
+	names := []string{"orders.created", "payments.settled", "orders.shipped", "orders.created"}
+	for _, name := range names {
+		_ = m.Emit(name).Wait(context.Background())
+	}
+
+	for _, want := range []string{"orders.created", "orders.shipped", "orders.created"} {
+		if got := <-received; got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+
+	unsubscribe()
+	_ = m.Emit("orders.cancelled").Wait(context.Background())
+
+	select {
+	case name := <-received:
+		t.Errorf("received %q after unsubscribing", name)
+	default:
+	}
+}
+
+func TestSubscribePatternBuiltinAndSource(t *testing.T) {
+	m := New()
+	s := &providerService{}
+
+	received := make(chan string, 100)
+	SubscribePattern(m, "service *", func(e Event) {
+		received <- e.Name
+	}, WithSource(s.Name()))
+
+	_ = m.Add(s).Wait(context.Background())
+	_ = m.Remove(s).Wait(context.Background())
+
+	for _, want := range []string{EventServiceAdded, EventServiceInitialized, EventServiceRemoved} {
+		if got := <-received; got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+// foreignMesh is a Mesh implementation with an event emitter of its own.
+type foreignMesh struct {
+	Mesh
+	events *ee.EventEmitter
+}
+
+func (f *foreignMesh) Events() EventEmitter { return f.events }
+
+func TestSubscribePatternForeignMesh(t *testing.T) {
+	wrapped := struct{ Mesh }{New()}
+
+	received := make(chan string, 1)
+	unsubscribe := SubscribePattern(wrapped, "orders.*", func(e Event) { received <- e.Name })
+	wrapped.Events().Emit("orders.created").Wait()
+	unsubscribe()
+
+	if name := <-received; name != "orders.created" {
+		t.Errorf("expected a mesh wrapping the mesh to be supported, got %q", name)
+	}
+
+	foreign := &foreignMesh{events: ee.New()}
+
+	unsubscribe = SubscribePattern(foreign, "orders.created", func(e Event) { received <- e.Name })
+	foreign.Events().Emit("orders.created", 42).Wait()
+	unsubscribe()
+
+	if name := <-received; name != "orders.created" {
+		t.Errorf("expected an exact pattern to be bound to the raw event emitter, got %q", name)
+	}
+
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrUnsupportedMesh) {
+			t.Errorf("expected a wildcard pattern to panic with ErrUnsupportedMesh, got %v", err)
+		}
+	}()
+
+	SubscribeAll(foreign, func(Event) {})
+}
@@ -0,0 +1,312 @@
+package servicemesh
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// RecentLogsTopic is the request topic which the mesh answers with the recent
+// log records of a service, while recent logs are retained (see
+// SetRecentLogRetention). The payload of the request is a RecentLogsQuery,
+// and the reply is a []LogRecord.
+const RecentLogsTopic = "recent logs"
+
+// LogRecord is a log record retained in the recent logs of a service.
+type LogRecord struct {
+	Time    time.Time      `json:"time"`
+	Level   slog.Level     `json:"level"`
+	Message string         `json:"message"`
+	Service string         `json:"service"`
+	Attrs   map[string]any `json:"attrs,omitempty"`
+}
+
+// RecentLogsQuery is the payload of a request for RecentLogsTopic.
+type RecentLogsQuery struct {
+	// Service is the name of the service.
+	Service string
+
+	// N is the maximum number of records to return, or zero for all of the
+	// retained records.
+	N int
+}
+
+// recentLogs holds the most recent log records of every service, by name.
+type recentLogs struct {
+	mu          sync.Mutex
+	retention   int
+	services    map[string]*logRing
+	unsubscribe Unsubscribe
+}
+
+// logRing is a ring buffer of log records.
+type logRing struct {
+	records []LogRecord
+	start   int
+	count   int
+}
+
+func (r *logRing) add(record LogRecord) {
+	if len(r.records) == 0 {
+		return
+	}
+
+	r.records[(r.start+r.count)%len(r.records)] = record
+
+	if r.count < len(r.records) {
+		r.count++
+	} else {
+		r.start = (r.start + 1) % len(r.records)
+	}
+}
+
+// last returns the last n records, oldest first, or all of them if n is not
+// positive.
+func (r *logRing) last(n int) []LogRecord {
+	if n <= 0 || n > r.count {
+		n = r.count
+	}
+
+	list := make([]LogRecord, 0, n)
+
+	for i := r.count - n; i < r.count; i++ {
+		list = append(list, r.records[(r.start+i)%len(r.records)])
+	}
+
+	return list
+}
+
+func (l *recentLogs) record(record LogRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ring, found := l.services[record.Service]
+	if !found {
+		ring = &logRing{records: make([]LogRecord, l.retention)}
+		l.services[record.Service] = ring
+	}
+
+	ring.add(record)
+}
+
+func (l *recentLogs) last(service string, n int) []LogRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ring, found := l.services[service]
+	if !found {
+		return nil
+	}
+
+	return ring.last(n)
+}
+
+// stopAnswering removes the request handler of the recent logs.
+func (l *recentLogs) stopAnswering() {
+	l.mu.Lock()
+	unsubscribe := l.unsubscribe
+	l.unsubscribe = nil
+	l.mu.Unlock()
+
+	if unsubscribe != nil {
+		unsubscribe()
+	}
+}
+
+// resize changes the number of records retained per service, keeping the
+// most recent ones.
+func (l *recentLogs) resize(retention int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.retention = retention
+
+	for name, ring := range l.services {
+		resized := &logRing{records: make([]LogRecord, retention)}
+
+		for _, record := range ring.last(retention) {
+			resized.add(record)
+		}
+
+		l.services[name] = resized
+	}
+}
+
+// recentHandler is a slog.Handler which retains the records of a service in
+// its recent logs, before passing them on.
+type recentHandler struct {
+	next    slog.Handler
+	logs    *recentLogs
+	service string
+	attrs   map[string]any
+	prefix  string
+}
+
+// Enabled reports true, so that records are retained even if the next
+// handler discards them. The log level of the service is applied before.
+func (h *recentHandler) Enabled(_ context.Context, _ slog.Level) bool {
+	return true
+}
+
+func (h *recentHandler) Handle(ctx context.Context, r slog.Record) error {
+	record := LogRecord{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Service: h.service,
+		Attrs:   make(map[string]any, len(h.attrs)+r.NumAttrs()),
+	}
+
+	for key, value := range h.attrs {
+		record.Attrs[key] = value
+	}
+
+	r.Attrs(func(attr slog.Attr) bool {
+		flattenAttr(record.Attrs, h.prefix, attr)
+		return true
+	})
+
+	h.logs.record(record)
+
+	if !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *recentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = make(map[string]any, len(h.attrs)+len(attrs))
+
+	for key, value := range h.attrs {
+		clone.attrs[key] = value
+	}
+
+	for _, attr := range attrs {
+		flattenAttr(clone.attrs, h.prefix, attr)
+	}
+
+	return &clone
+}
+
+func (h *recentHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.prefix = h.prefix + name + "."
+
+	return &clone
+}
+
+// flattenAttr adds an attribute to a map, with the keys of groups joined by
+// dots.
+func flattenAttr(attrs map[string]any, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+
+	if value.Kind() != slog.KindGroup {
+		if attr.Key != "" {
+			attrs[prefix+attr.Key] = value.Any()
+		}
+
+		return
+	}
+
+	if attr.Key != "" {
+		prefix += attr.Key + "."
+	}
+
+	for _, member := range value.Group() {
+		flattenAttr(attrs, prefix, member)
+	}
+}
+
+// SetRecentLogRetention sets the number of log records which the mesh
+// retains in memory for every service, so that they can be looked up with
+// RecentLogs or by requesting RecentLogsTopic. Records are retained at the
+// log level of the service, regardless of the log handler. A retention of
+// zero, the default, disables the recent logs. Child meshes share the recent
+// logs of their parent.
+func (m *mesh) SetRecentLogRetention(n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	m.logMu.Lock()
+
+	logs := m.recentLogs
+
+	switch {
+	case n == 0:
+		m.recentLogs = nil
+	case logs == nil:
+		m.recentLogs = &recentLogs{retention: n, services: make(map[string]*logRing)}
+	default:
+		logs.resize(n)
+	}
+
+	enabled := m.recentLogs
+
+	m.logMu.Unlock()
+
+	switch {
+	case n == 0 && logs != nil:
+		logs.stopAnswering()
+	case n > 0 && logs == nil:
+		m.handleRecentLogs(enabled)
+	}
+
+	m.updateServiceLoggers()
+}
+
+// handleRecentLogs answers requests for RecentLogsTopic, until the recent
+// logs are disabled.
+func (m *mesh) handleRecentLogs(logs *recentLogs) {
+	unsubscribe, err := m.Handle(RecentLogsTopic, func(_ context.Context, payload any) (any, error) {
+		query, ok := payload.(RecentLogsQuery)
+		if !ok {
+			return nil, fmt.Errorf("%w: %T", ErrInvalidPayload, payload)
+		}
+
+		return m.RecentLogs(query.Service, query.N), nil
+	})
+	if err != nil {
+		m.logger.Warn("answering requests for recent logs", "error", err)
+		return
+	}
+
+	logs.mu.Lock()
+	logs.unsubscribe = unsubscribe
+	logs.mu.Unlock()
+}
+
+// RecentLogs returns the last n log records of the services with the given
+// name, oldest first, or all of the retained records if n is zero. Nothing is
+// returned unless recent logs are retained, see SetRecentLogRetention.
+func (m *mesh) RecentLogs(service string, n int) []LogRecord {
+	m.logMu.RLock()
+	logs := m.recentLogs
+	m.logMu.RUnlock()
+
+	if logs == nil {
+		return nil
+	}
+
+	return logs.last(service, n)
+}
+
+// withRecentLogs retains the records of a service in the recent logs of the
+// mesh, if they are enabled.
+func (m *mesh) withRecentLogs(service Service, handler slog.Handler) slog.Handler {
+	m.logMu.RLock()
+	logs := m.recentLogs
+	m.logMu.RUnlock()
+
+	if logs == nil {
+		return handler
+	}
+
+	return &recentHandler{next: handler, logs: logs, service: service.Name()}
+}
@@ -0,0 +1,62 @@
+package servicemesh
+
+// Lookup finds a service of the mesh or one of its ancestors by name, see
+// Get. If the service was added with AddLazy, it is initialized before being
+// returned. Services which report themselves unhealthy through HasHealth are
+// skipped.
+func (m *mesh) Lookup(name string) (Service, bool) {
+	for owner := m; owner != nil; owner = owner.parent {
+		for _, service := range owner.Services() {
+			if service.Name() != name || !isHealthy(service) {
+				continue
+			}
+
+			// scoped instances of an ancestor are not shared with children
+			if owner != m && owner.isScopedInstance(service) {
+				continue
+			}
+
+			if err := owner.ensureInitialized(service); err != nil {
+				m.logger.Warn("lookup failed", "service", name, "error", err)
+				return nil, false
+			}
+
+			return service, true
+		}
+	}
+
+	return nil, false
+}
+
+// Get finds the first service of the mesh which is of type T. Typically, T is
+// an interface which the desired service implements. If the service was
+// added with AddLazy, it is initialized before being returned.
+//
+// The services of ancestor meshes and any factories registered with
+// AddFactory are also considered, see Resolve.
+func Get[T any](m Mesh) (T, bool) {
+	var zero T
+
+	if _, ok := m.(*mesh); ok {
+		candidate, err := Resolve[T](m)
+		return candidate, err == nil
+	}
+
+	for _, service := range m.Services() {
+		candidate, ok := service.(T)
+		if !ok {
+			continue
+		}
+
+		return candidate, true
+	}
+
+	return zero, false
+}
+
+// isHealthy returns false for a service which reports itself unhealthy
+// through HasHealth, and true for any other service.
+func isHealthy(service Service) bool {
+	candidate, ok := service.(HasHealth)
+	return !ok || candidate.Healthy()
+}
@@ -0,0 +1,318 @@
+package servicemesh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+// RemoteService is a service which stands in for a service exported by a
+// ServiceExporter in another process, and forwards calls to it with Call.
+// It connects when added to a mesh, and reconnects with a backoff whenever
+// the connection is lost. Whether it is currently connected is reported by
+// Healthy.
+//
+// Go cannot generate an implementation of an interface at runtime, so to use
+// a remote service where a Go interface is expected, generate a typed adapter
+// with the servicemesh-proxy command, next to the declaration of the
+// interface:
+//
+//	//go:generate go run github.com/gravestench/servicemesh/cmd/servicemesh-proxy -type Billing
+//
+// The generated BillingProxy embeds the RemoteService and implements each
+// method of the interface with Call:
+//
+//	func (p *BillingProxy) Charge(a0 int) (string, error) {
+//		var r0 string
+//
+//		err := p.Call(context.Background(), "Charge", &r0, a0)
+//
+//		return r0, err
+//	}
+//
+// Added to a mesh, the adapter is resolved by dependent services like any
+// local service implementing the interface.
+type RemoteService struct {
+	mu           sync.Mutex
+	logger       *slog.Logger
+	name         string
+	network      string
+	address      string
+	reconnectMin time.Duration
+	reconnectMax time.Duration
+	conn         net.Conn
+	encoder      *json.Encoder
+	pending      map[uint64]chan rpcResponse
+	nextID       uint64
+	quit         chan struct{}
+	wg           sync.WaitGroup
+}
+
+// NewRemoteService creates a proxy for the service with the given name,
+// exported by the ServiceExporter listening on the given network and
+// address.
+func NewRemoteService(name, network, address string) *RemoteService {
+	return &RemoteService{
+		name:         name,
+		network:      network,
+		address:      address,
+		reconnectMin: defaultReconnectMin,
+		reconnectMax: defaultReconnectMax,
+		pending:      make(map[uint64]chan rpcResponse),
+		quit:         make(chan struct{}),
+	}
+}
+
+// SetReconnect sets the delay before the first attempt to reconnect, and the
+// maximum delay it doubles up to with every failed attempt.
+func (r *RemoteService) SetReconnect(initial, limit time.Duration) {
+	r.reconnectMin = initial
+	r.reconnectMax = limit
+}
+
+// Init starts connecting to the exporter of the remote service.
+func (r *RemoteService) Init(_ Mesh) {
+	r.wg.Add(1)
+	go r.connect()
+}
+
+// Name returns the name of the remote service.
+func (r *RemoteService) Name() string {
+	return r.name
+}
+
+// SetLogger sets the logger of the service.
+func (r *RemoteService) SetLogger(l *slog.Logger) {
+	r.logger = l
+}
+
+// Logger yields the logger of the service.
+func (r *RemoteService) Logger() *slog.Logger {
+	return r.logger
+}
+
+// Healthy returns true while the remote service is connected.
+func (r *RemoteService) Healthy() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.conn != nil
+}
+
+// OnShutdown closes the connection to the remote service.
+func (r *RemoteService) OnShutdown() {
+	close(r.quit)
+
+	r.mu.Lock()
+	if r.conn != nil {
+		_ = r.conn.Close()
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+}
+
+// Call calls a method of the remote service with the given arguments, and
+// decodes the first result of the method (other than a trailing error) into
+// reply, unless reply is nil. For a method with several results, reply may be
+// a pointer to a slice.
+//
+// If the remote service is not connected, ErrRemoteUnavailable is returned.
+// If the method fails on the remote side, a *RemoteCallError matching
+// ErrRemoteCall is returned. If the context has no deadline, the call times
+// out after 30 seconds.
+func (r *RemoteService) Call(ctx context.Context, method string, reply any, args ...any) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultRequestTimeout)
+		defer cancel()
+	}
+
+	req := rpcRequest{Version: "2.0", Method: r.name + "." + method}
+
+	for i, arg := range args {
+		data, err := json.Marshal(arg)
+		if err != nil {
+			return fmt.Errorf("argument %d: %w", i, err)
+		}
+
+		req.Params = append(req.Params, data)
+	}
+
+	done := make(chan rpcResponse, 1)
+
+	r.mu.Lock()
+
+	if r.conn == nil {
+		r.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrRemoteUnavailable, r.name)
+	}
+
+	r.nextID++
+	req.ID = r.nextID
+	r.pending[req.ID] = done
+
+	err := r.encoder.Encode(req)
+
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, req.ID)
+		r.mu.Unlock()
+	}()
+
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrRemoteUnavailable, r.name, err)
+	}
+
+	var resp rpcResponse
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case resp = <-done:
+	}
+
+	if resp.Error != nil && resp.Error.Code == rpcDisconnected {
+		return fmt.Errorf("%w: %s: connection lost", ErrRemoteUnavailable, r.name)
+	}
+
+	if resp.Error != nil {
+		return &RemoteCallError{Code: resp.Error.Code, Message: resp.Error.Message}
+	}
+
+	if reply == nil || len(resp.Result) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(resp.Result, reply)
+}
+
+// CallResults calls a method with several results (other than a trailing
+// error), like Call, and decodes each result into the corresponding reply.
+func (r *RemoteService) CallResults(ctx context.Context, method string, replies []any, args ...any) error {
+	var results []json.RawMessage
+
+	if err := r.Call(ctx, method, &results, args...); err != nil {
+		return err
+	}
+
+	if len(results) != len(replies) {
+		return fmt.Errorf("%s.%s: expected %d results, got %d", r.name, method, len(replies), len(results))
+	}
+
+	for i, result := range results {
+		if err := json.Unmarshal(result, replies[i]); err != nil {
+			return fmt.Errorf("%s.%s: result %d: %w", r.name, method, i, err)
+		}
+	}
+
+	return nil
+}
+
+// LogCallError logs the failure of a call which cannot be returned to the
+// caller, such as a call made by a generated proxy for a method without an
+// error result. Without a logger, the failure is written to the default
+// logger.
+func (r *RemoteService) LogCallError(method string, err error) {
+	logger := r.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	logger.Error("remote call failed", "service", r.name, "method", method, "error", err)
+}
+
+// connect keeps a connection to the exporter of the remote service, until
+// the service shuts down.
+func (r *RemoteService) connect() {
+	defer r.wg.Done()
+
+	backoff := r.reconnectMin
+
+	for {
+		conn, err := net.Dial(r.network, r.address)
+		if err == nil {
+			r.logger.Info("connected to remote service", "address", r.address)
+			backoff = r.reconnectMin
+
+			r.serve(conn)
+
+			select {
+			case <-r.quit:
+				return
+			default:
+			}
+
+			r.logger.Warn("lost connection to remote service", "address", r.address)
+		} else {
+			r.logger.Debug("connecting to remote service", "address", r.address, "error", err, "retry", backoff)
+		}
+
+		select {
+		case <-r.quit:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, r.reconnectMax)
+	}
+}
+
+// serve reads the responses to calls from a connection, until it is closed.
+// The calls which are still pending once the connection is lost fail with
+// ErrRemoteUnavailable.
+func (r *RemoteService) serve(conn net.Conn) {
+	r.mu.Lock()
+
+	select {
+	case <-r.quit:
+		r.mu.Unlock()
+		_ = conn.Close()
+
+		return
+	default:
+	}
+
+	r.conn = conn
+	r.encoder = json.NewEncoder(conn)
+	r.mu.Unlock()
+
+	decoder := json.NewDecoder(conn)
+
+	for {
+		var resp rpcResponse
+
+		if err := decoder.Decode(&resp); err != nil {
+			break
+		}
+
+		r.mu.Lock()
+		done, found := r.pending[resp.ID]
+		delete(r.pending, resp.ID)
+		r.mu.Unlock()
+
+		if found {
+			done <- resp
+		}
+	}
+
+	r.mu.Lock()
+
+	r.conn = nil
+	r.encoder = nil
+
+	for id, done := range r.pending {
+		done <- rpcResponse{ID: id, Error: &rpcError{Code: rpcDisconnected}}
+		delete(r.pending, id)
+	}
+
+	r.mu.Unlock()
+
+	_ = conn.Close()
+}
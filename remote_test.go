@@ -0,0 +1,172 @@
+package servicemesh
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//go:generate go run ./cmd/servicemesh-proxy -type calculator -service Calculator -output calculator_proxy_test.go
+
+type adder interface {
+	Add(a, b int) (int, error)
+}
+
+// calculator is the interface of the remote calculator, implemented by the
+// generated calculatorProxy.
+type calculator interface {
+	Add(a, b int) (int, error)
+	DivMod(ctx context.Context, a, b int) (int, int)
+}
+
+var _ calculator = (*calculatorProxy)(nil)
+
+type calculatorService struct {
+	calls int
+}
+
+func (c *calculatorService) Init(_ Mesh)  {}
+func (c *calculatorService) Name() string { return "Calculator" }
+
+func (c *calculatorService) Add(a, b int) (int, error) {
+	c.calls++
+
+	if a < 0 || b < 0 {
+		return 0, errors.New("negative operand")
+	}
+
+	return a + b, nil
+}
+
+func (c *calculatorService) DivMod(_ context.Context, a, b int) (int, int) {
+	return a / b, a % b
+}
+
+// adderClient guards its dependency, since it is resolved by the dependency
+// watcher while tests use it.
+type adderClient struct {
+	mu    sync.Mutex
+	adder adder
+}
+
+func (c *adderClient) dependency() adder {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.adder
+}
+
+func (c *adderClient) Init(_ Mesh)                {}
+func (c *adderClient) Name() string               { return "Adder Client" }
+func (c *adderClient) DependenciesResolved() bool { return c.dependency() != nil }
+func (c *adderClient) ResolveDependencies(services []Service) {
+	for _, service := range services {
+		if candidate, ok := service.(adder); ok {
+			c.mu.Lock()
+			c.adder = candidate
+			c.mu.Unlock()
+		}
+	}
+}
+
+func exportCalculator(t *testing.T, socket string) *ServiceExporter {
+	t.Helper()
+
+	exporter := NewServiceExporter()
+	exporter.Listen("unix", socket)
+	exporter.Export(&calculatorService{})
+
+	_ = New("exporting").Add(exporter).Wait(context.Background())
+
+	return exporter
+}
+
+func TestRemoteService(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "rpc.sock")
+	exporter := exportCalculator(t, socket)
+
+	m := New("importing")
+
+	logs := &logBuffer{}
+	m.SetLogDestination(logs)
+
+	proxy := newCalculatorProxy("unix", socket)
+	remote := proxy.RemoteService
+	remote.SetReconnect(5*time.Millisecond, 20*time.Millisecond)
+
+	defer remote.OnShutdown()
+
+	client := &adderClient{}
+	m.Add(client)
+	_ = m.Add(proxy).Wait(context.Background())
+
+	waitFor(t, "the remote service to connect", remote.Healthy)
+	waitFor(t, "the dependency on the proxy to resolve", client.DependenciesResolved)
+
+	sum, err := client.dependency().Add(2, 3)
+	if err != nil || sum != 5 {
+		t.Errorf("expected 5, got %d (%v)", sum, err)
+	}
+
+	if _, err = client.dependency().Add(-1, 3); !errors.Is(err, ErrRemoteCall) || err.Error() != "negative operand" {
+		t.Errorf("expected the remote error, got %v", err)
+	}
+
+	var quotient []int
+	if err = remote.Call(context.Background(), "DivMod", &quotient, 7, 2); err != nil || len(quotient) != 2 || quotient[0] != 3 || quotient[1] != 1 {
+		t.Errorf("expected [3 1], got %v (%v)", quotient, err)
+	}
+
+	if q, r := proxy.DivMod(context.Background(), 7, 2); q != 3 || r != 1 {
+		t.Errorf("expected the generated proxy to decode both results, got %d, %d", q, r)
+	}
+
+	var rcErr *RemoteCallError
+	if err = remote.Call(context.Background(), "Missing", nil); !errors.As(err, &rcErr) || rcErr.Code != rpcMethodNotFound {
+		t.Errorf("expected an unknown method to fail, got %v", err)
+	}
+
+	// the lifecycle methods of an exported service cannot be called
+	for _, method := range []string{"Init", "Name"} {
+		if err = remote.Call(context.Background(), method, nil); !errors.As(err, &rcErr) || rcErr.Code != rpcMethodNotFound {
+			t.Errorf("expected %s not to be callable, got %v", method, err)
+		}
+	}
+
+	// losing the exporter makes the proxy unhealthy, until it is back
+	exporter.OnShutdown()
+
+	waitFor(t, "the remote service to disconnect", func() bool {
+		return !remote.Healthy()
+	})
+
+	// an unhealthy service is listed, but not looked up
+	if _, found := m.Lookup("Calculator"); found || !containsService(m.Services(), proxy) {
+		t.Errorf("expected the disconnected proxy to be listed but not looked up")
+	}
+
+	if _, found := Get[calculator](m); found {
+		t.Errorf("expected the disconnected proxy not to be resolved")
+	}
+
+	if _, err = client.dependency().Add(1, 1); !errors.Is(err, ErrRemoteUnavailable) {
+		t.Errorf("expected the call to fail while disconnected, got %v", err)
+	}
+
+	// a method without an error result logs the failure instead
+	if q, r := proxy.DivMod(context.Background(), 7, 2); q != 0 || r != 0 || !strings.Contains(logs.String(), "remote call failed") {
+		t.Errorf("expected the failed call to be logged, got %d, %d and logs %q", q, r, logs.String())
+	}
+
+	defer exportCalculator(t, socket).OnShutdown()
+
+	waitFor(t, "the remote service to reconnect", remote.Healthy)
+
+	if sum, err = client.dependency().Add(1, 1); err != nil || sum != 2 {
+		t.Errorf("expected 2 after reconnecting, got %d (%v)", sum, err)
+	}
+}
@@ -0,0 +1,219 @@
+package servicemesh
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ReplicaFactory creates a single replica of a replicated service. The given
+// name is unique among the replicas of the set (eg. "worker-0", "worker-1"),
+// and should be returned by the Name method of the created service.
+type ReplicaFactory func(name string) Service
+
+// replicaSet is a group of interchangeable services, created with
+// AddReplicas and resized with Scale.
+type replicaSet struct {
+	name     string
+	factory  ReplicaFactory
+	mu       sync.Mutex
+	replicas []Service
+	next     int
+}
+
+// AddReplicas creates n replicas of a service with the given factory, and
+// adds them to the mesh. The replicas are named after the replica set, with
+// their index as a suffix. The returned Operation completes once all of the
+// replicas have been initialized.
+//
+// Typed lookups with Get or Resolve which match a replica are load-balanced
+// across all ready replicas of the set, skipping replicas which report
+// themselves unhealthy through HasHealth.
+func (m *mesh) AddReplicas(name string, factory ReplicaFactory, n int) *Operation {
+	if n < 0 {
+		return completedOperation(fmt.Errorf("%w: %d", ErrInvalidReplicaCount, n))
+	}
+
+	m.mu.Lock()
+
+	if _, found := m.replicaSets[name]; found {
+		m.mu.Unlock()
+		return completedOperation(fmt.Errorf("%w: %s", ErrReplicaSetExists, name))
+	}
+
+	if m.replicaSets == nil {
+		m.replicaSets = make(map[string]*replicaSet)
+	}
+
+	set := &replicaSet{name: name, factory: factory}
+	m.replicaSets[name] = set
+
+	m.mu.Unlock()
+
+	return m.scale(set, n)
+}
+
+// Scale changes the number of replicas of a replica set which was created
+// with AddReplicas. Replicas are added or removed from the end of the set,
+// and removed replicas are gracefully shut down. A replica which is removed
+// from the mesh with Remove leaves the set, and its name is reused by the
+// next replica added.
+func (m *mesh) Scale(name string, n int) *Operation {
+	if n < 0 {
+		return completedOperation(fmt.Errorf("%w: %d", ErrInvalidReplicaCount, n))
+	}
+
+	m.mu.RLock()
+	set, found := m.replicaSets[name]
+	m.mu.RUnlock()
+
+	if !found {
+		return completedOperation(fmt.Errorf("%w: %s", ErrReplicaSetNotFound, name))
+	}
+
+	return m.scale(set, n)
+}
+
+// Replicas returns the current replicas of a replica set.
+func (m *mesh) Replicas(name string) (list []Service) {
+	m.mu.RLock()
+	set, found := m.replicaSets[name]
+	m.mu.RUnlock()
+
+	if !found {
+		return nil
+	}
+
+	set.mu.Lock()
+	defer set.mu.Unlock()
+
+	return append(list, set.replicas...)
+}
+
+func (m *mesh) scale(set *replicaSet, n int) *Operation {
+	set.mu.Lock()
+
+	var added, removed []Service
+
+	for len(set.replicas) < n {
+		replica := set.factory(set.nextName())
+		set.replicas = append(set.replicas, replica)
+		added = append(added, replica)
+	}
+
+	for len(set.replicas) > n {
+		last := len(set.replicas) - 1
+		removed = append(removed, set.replicas[last])
+		set.replicas = set.replicas[:last]
+	}
+
+	set.mu.Unlock()
+
+	m.mu.Lock()
+	if m.replicaOf == nil {
+		m.replicaOf = make(map[Service]*replicaSet)
+	}
+
+	for _, replica := range added {
+		m.replicaOf[replica] = set
+	}
+
+	for _, replica := range removed {
+		delete(m.replicaOf, replica)
+	}
+	m.mu.Unlock()
+
+	pending := make([]*Operation, 0, len(added)+len(removed)+1)
+
+	for _, replica := range added {
+		pending = append(pending, m.Add(replica))
+	}
+
+	for _, replica := range removed {
+		if quitter, ok := replica.(HasGracefulShutdown); ok {
+			pending = append(pending, completedOperation(m.safeShutdownService(quitter)))
+		}
+
+		pending = append(pending, m.Remove(replica))
+	}
+
+	pending = append(pending, operationFromWaitGroup(m.emit(EventReplicasScaled, set.name, n)))
+
+	return joinOperations(pending...)
+}
+
+// nextName yields the name of a new replica, suffixed with the lowest index
+// which is not taken by another replica of the set. The lock of the set must
+// be held.
+func (s *replicaSet) nextName() string {
+	for i := 0; ; i++ {
+		name := fmt.Sprintf("%s-%d", s.name, i)
+
+		taken := false
+
+		for _, replica := range s.replicas {
+			taken = taken || replica.Name() == name
+		}
+
+		if !taken {
+			return name
+		}
+	}
+}
+
+// forgetReplica drops a removed service from the replica set it belongs to,
+// if any.
+func (m *mesh) forgetReplica(service Service) {
+	m.mu.Lock()
+	set, found := m.replicaOf[service]
+	delete(m.replicaOf, service)
+	m.mu.Unlock()
+
+	if !found {
+		return
+	}
+
+	set.mu.Lock()
+	defer set.mu.Unlock()
+
+	for i, replica := range set.replicas {
+		if replica == service {
+			set.replicas = append(set.replicas[:i], set.replicas[i+1:]...)
+			break
+		}
+	}
+
+	if set.next >= len(set.replicas) {
+		set.next = 0
+	}
+}
+
+// nextReplica picks the next ready and healthy replica of the replica set which the
+// given service belongs to, rotating through the replicas on every call. It
+// returns nil if the service is not a replica.
+func (m *mesh) nextReplica(service Service, typ reflect.Type) Service {
+	m.mu.RLock()
+	set, found := m.replicaOf[service]
+	m.mu.RUnlock()
+
+	if !found {
+		return nil
+	}
+
+	set.mu.Lock()
+	defer set.mu.Unlock()
+
+	for i := 0; i < len(set.replicas); i++ {
+		candidate := set.replicas[(set.next+i)%len(set.replicas)]
+
+		if !m.isReady(candidate) || !isHealthy(candidate) || !reflect.TypeOf(candidate).AssignableTo(typ) {
+			continue
+		}
+
+		set.next = (set.next + i + 1) % len(set.replicas)
+
+		return candidate
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package servicemesh
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReplicas(t *testing.T) {
+	m := New()
+
+	factory := func(name string) Service {
+		return &workerService{name: name}
+	}
+
+	if err := m.AddReplicas("worker", factory, 3).Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]int)
+
+	for i := 0; i < 6; i++ {
+		w, found := Get[*workerService](m)
+		if !found {
+			t.Fatal("expected a replica")
+		}
+
+		seen[w.Name()]++
+	}
+
+	for _, name := range []string{"worker-0", "worker-1", "worker-2"} {
+		if seen[name] != 2 {
+			t.Errorf("expected lookups to be balanced across replicas, got %v", seen)
+			break
+		}
+	}
+
+	if err := m.Scale("worker", 1).Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(m.Replicas("worker")); n != 1 {
+		t.Errorf("expected 1 replica after scaling down, got %d", n)
+	}
+
+	if _, found := m.Lookup("worker-2"); found {
+		t.Error("scaled down replicas should be removed")
+	}
+}
+
+func TestReplicasRemovedOrUnhealthy(t *testing.T) {
+	m := New()
+
+	factory := func(name string) Service {
+		return &workerService{name: name}
+	}
+
+	if err := m.AddReplicas("worker", factory, 3).Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	replicas := m.Replicas("worker")
+	replicas[0].(*workerService).unhealthy.Store(true)
+
+	for i := 0; i < 4; i++ {
+		if w, _ := Get[*workerService](m); w == replicas[0] {
+			t.Fatal("expected lookups to skip the unhealthy replica")
+		}
+	}
+
+	if err := m.Remove(replicas[1]).Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(m.Replicas("worker")); n != 2 {
+		t.Fatalf("expected the removed replica to leave the set, got %d replicas", n)
+	}
+
+	if err := m.Scale("worker", 3).Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool)
+
+	for _, replica := range m.Replicas("worker") {
+		names[replica.Name()] = true
+	}
+
+	if len(names) != 3 || !names["worker-1"] {
+		t.Errorf("expected the set to be scaled back to 3 distinct replicas, got %v", names)
+	}
+}
+
+type workerService struct {
+	name      string
+	unhealthy atomic.Bool
+}
+
+func (w *workerService) Init(_ Mesh) {}
+
+func (w *workerService) Name() string { return w.name }
+
+func (w *workerService) Healthy() bool { return !w.unhealthy.Load() }
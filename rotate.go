@@ -0,0 +1,220 @@
+package servicemesh
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// compressedSuffix is appended to the name of rotated files which are
+// compressed.
+const compressedSuffix = ".gz"
+
+// Rotation describes when a RotatingFile is rotated, and what is kept of the
+// rotated files. The zero value never rotates.
+type Rotation struct {
+	// MaxSize is the size in bytes at which the file is rotated, or zero
+	// for no size limit.
+	MaxSize int64
+
+	// MaxAge is how long the file is written to before it is rotated, or
+	// zero for no age limit.
+	MaxAge time.Duration
+
+	// MaxBackups is the number of rotated files to keep.
+	MaxBackups int
+
+	// Compress makes rotated files be compressed with gzip, adding ".gz" to
+	// their names.
+	Compress bool
+}
+
+// RotatingFile is an io.Writer which appends to a file, and rotates the file
+// once it exceeds the size or age limits of its Rotation. Rotated files are
+// renamed with a numeric suffix (eg. "events.jsonl.1"), with the highest
+// number being the oldest.
+//
+// A RotatingFile can be given to SetLogDestination, or to any other writer
+// of logs; the file is opened on the first write.
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	rotation Rotation
+	file     *os.File
+	size     int64
+	opened   time.Time
+}
+
+// NewRotatingFile creates a writer for the file at the given path, which is
+// rotated as described by the rotation.
+func NewRotatingFile(path string, rotation Rotation) *RotatingFile {
+	return &RotatingFile{
+		path:     path,
+		rotation: rotation,
+	}
+}
+
+// Write appends to the file, rotating the file first if the write would
+// exceed the maximum size, or if the file has reached its maximum age.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.size > 0 && r.due(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// Rotate rotates the file, regardless of its size and age.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+
+	return r.rotate()
+}
+
+// Close closes the underlying file. The file is opened again by the next
+// write.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+
+	return err
+}
+
+// due reports whether the file must be rotated before writing n bytes.
+func (r *RotatingFile) due(n int64) bool {
+	if r.rotation.MaxSize > 0 && r.size+n > r.rotation.MaxSize {
+		return true
+	}
+
+	return r.rotation.MaxAge > 0 && time.Since(r.opened) >= r.rotation.MaxAge
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	r.file = file
+	r.size = info.Size()
+	r.opened = time.Now()
+
+	return nil
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	r.file = nil
+
+	maxBackups := r.rotation.MaxBackups
+
+	// the oldest backup falls off the end
+	r.removeBackup(maxBackups)
+
+	for i := maxBackups - 1; i >= 1; i-- {
+		_ = os.Rename(backupName(r.path, i), backupName(r.path, i+1))
+		_ = os.Rename(backupName(r.path, i)+compressedSuffix, backupName(r.path, i+1)+compressedSuffix)
+	}
+
+	if maxBackups > 0 {
+		if err := os.Rename(r.path, backupName(r.path, 1)); err != nil {
+			return err
+		}
+
+		if r.rotation.Compress {
+			if err := compressFile(backupName(r.path, 1)); err != nil {
+				return err
+			}
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return err
+	}
+
+	return r.open()
+}
+
+// removeBackup removes a rotated file, whether it was compressed or not.
+func (r *RotatingFile) removeBackup(n int) {
+	_ = os.Remove(backupName(r.path, n))
+	_ = os.Remove(backupName(r.path, n) + compressedSuffix)
+}
+
+// compressFile replaces a file with a gzip compressed copy.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.Create(path + compressedSuffix)
+	if err != nil {
+		_ = src.Close()
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+
+	_, err = io.Copy(zw, src)
+
+	for _, closer := range []io.Closer{zw, dst, src} {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	if err != nil {
+		_ = os.Remove(path + compressedSuffix)
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
@@ -0,0 +1,91 @@
+package servicemesh
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	file := NewRotatingFile(path, Rotation{MaxSize: 10, MaxBackups: 2, Compress: true})
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_ = file.Close()
+
+	if data, _ := os.ReadFile(path); string(data) != "fourth\n" {
+		t.Errorf("expected the current file to hold the last write, got %q", data)
+	}
+
+	if got := readCompressed(t, backupName(path, 1)+compressedSuffix); got != "third\n" {
+		t.Errorf("expected the newest backup to be compressed, got %q", got)
+	}
+
+	if got := readCompressed(t, backupName(path, 2)+compressedSuffix); got != "second\n" {
+		t.Errorf("expected the older backup to be kept, got %q", got)
+	}
+
+	if _, err := os.Stat(backupName(path, 3) + compressedSuffix); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 backups to be retained")
+	}
+
+	if _, err := os.Stat(backupName(path, 1)); !os.IsNotExist(err) {
+		t.Errorf("expected the uncompressed backup to be removed")
+	}
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	file := NewRotatingFile(path, Rotation{MaxAge: 20 * time.Millisecond, MaxBackups: 1})
+	defer file.Close()
+
+	_, _ = file.Write([]byte("old\n"))
+	_, _ = file.Write([]byte("recent\n"))
+
+	if _, err := os.Stat(backupName(path, 1)); !os.IsNotExist(err) {
+		t.Errorf("expected no rotation before the maximum age")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	_, _ = file.Write([]byte("new\n"))
+
+	if data, _ := os.ReadFile(backupName(path, 1)); string(data) != "old\nrecent\n" {
+		t.Errorf("expected the file to be rotated once it reached its maximum age, got %q", data)
+	}
+}
+
+func readCompressed(t *testing.T, path string) string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data strings.Builder
+
+	if _, err = io.Copy(&data, zr); err != nil {
+		t.Fatal(err)
+	}
+
+	return data.String()
+}
@@ -0,0 +1,213 @@
+package servicemesh
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultRequestTimeout = time.Second * 30
+
+// RequestHandler responds to requests made with Request for a particular
+// topic. The context is that of the caller, so a handler should give up once
+// the context is done.
+type RequestHandler func(ctx context.Context, payload any) (reply any, err error)
+
+// rpcCall is passed along with the payload of a request on the event bus,
+// and carries the reply back to the caller.
+type rpcCall struct {
+	ctx   context.Context
+	reply chan rpcReply
+}
+
+type rpcReply struct {
+	value any
+	err   error
+}
+
+// requestTopic yields the name of the event which requests for a topic are
+// emitted with.
+func requestTopic(topic string) string {
+	return "request " + topic
+}
+
+// Handle registers the handler for requests of a topic. Only a single
+// handler may be registered for a topic within a mesh, registering another
+// yields ErrResponderExists. The returned function removes the handler.
+func (m *mesh) Handle(topic string, handler RequestHandler) (Unsubscribe, error) {
+	m.mu.Lock()
+
+	if m.responders[topic] {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrResponderExists, topic)
+	}
+
+	if m.responders == nil {
+		m.responders = make(map[string]bool)
+	}
+
+	m.responders[topic] = true
+
+	m.mu.Unlock()
+
+	unsubscribe := m.subscribe(requestTopic(topic), func(e Event) {
+		if len(e.Args) < 2 {
+			return
+		}
+
+		call, ok := e.Args[1].(*rpcCall)
+		if !ok {
+			return
+		}
+
+		// requests are handled concurrently, rather than one at a time
+		go m.respond(topic, handler, e.Args[0], call)
+	})
+
+	return func() {
+		unsubscribe()
+
+		m.mu.Lock()
+		delete(m.responders, topic)
+		m.mu.Unlock()
+	}, nil
+}
+
+func (m *mesh) respond(topic string, handler RequestHandler, payload any, call *rpcCall) {
+	var reply rpcReply
+
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Error("request handler panicked", "topic", topic, "panic", r)
+			reply = rpcReply{err: fmt.Errorf("%w: %s: %v", ErrHandlerPanicked, topic, r)}
+		}
+
+		// the reply channel is buffered, so this never blocks even if the
+		// caller has stopped waiting
+		call.reply <- reply
+	}()
+
+	value, err := handler(call.ctx, payload)
+	reply = rpcReply{value: value, err: err}
+}
+
+// Request sends a request with the given payload to the handler of a topic,
+// and waits for its reply. The handler is looked for in this mesh and then in
+// each of its ancestors. If no handler is registered, ErrNoResponder is
+// returned, and if the request is dropped by the middleware or because the
+// queue of the handler is full, ErrRequestNotDelivered is returned. If the
+// context has no deadline, the request times out after 30 seconds.
+func (m *mesh) Request(ctx context.Context, topic string, payload any) (any, error) {
+	responder := m.responderFor(topic)
+	if responder == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoResponder, topic)
+	}
+
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultRequestTimeout)
+
+		defer cancel()
+	}
+
+	call := &rpcCall{ctx: ctx, reply: make(chan rpcReply, 1)}
+	env := Envelope{Source: m.name, SourceID: m.ServiceID(m), CorrelationID: newID()}
+
+	if !responder.dispatchRequest(requestTopic(topic), []any{payload, call}, env) {
+		return nil, fmt.Errorf("%w: %s", ErrRequestNotDelivered, topic)
+	}
+
+	select {
+	case reply := <-call.reply:
+		return reply.value, reply.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("request %s: %w", topic, ctx.Err())
+	}
+}
+
+// dispatchRequest publishes the event of a request to the handler of its
+// topic alone. Unlike other events, requests are not recorded in the event
+// history, are not seen by pattern subscribers such as journals and bridges,
+// and are not bubbled up to the parent mesh. It reports whether the request
+// was handed to the handler.
+func (m *mesh) dispatchRequest(event string, args []any, env Envelope) bool {
+	m.stamp(&env)
+
+	delivered := false
+
+	publish := func(e Event) {
+		delivered = m.publishRequest(e)
+	}
+
+	// the middleware may alter or drop the request, just like other events
+	m.withMiddleware(publish)(Event{Name: event, Args: args, Envelope: env})
+
+	return delivered
+}
+
+// publishRequest delivers the event of a request to the subscribers of its
+// name, and reports whether any of them received it. The request shares the
+// sequence numbers of the events published on the bus.
+func (m *mesh) publishRequest(e Event) bool {
+	m.bus.order.Lock()
+
+	e.Envelope.Sequence = m.nextSequence()
+
+	m.bus.mu.RLock()
+	subs := append([]*subscription(nil), m.bus.subs[e.Name]...)
+	m.bus.mu.RUnlock()
+
+	delivered := false
+
+	var synchronous []*subscription
+
+	for _, sub := range subs {
+		if !sub.matches(e) {
+			continue
+		}
+
+		if sub.mode == DeliverySync {
+			synchronous = append(synchronous, sub)
+			continue
+		}
+
+		// nobody waits for the delivery, the reply is awaited instead
+		var wg sync.WaitGroup
+
+		wg.Add(1)
+
+		if m.enqueue(sub, delivery{event: e, wg: &wg}) {
+			delivered = true
+		}
+	}
+
+	m.bus.order.Unlock()
+
+	for _, sub := range synchronous {
+		var wg sync.WaitGroup
+
+		wg.Add(1)
+		m.deliverSync(sub, delivery{event: e, wg: &wg})
+
+		delivered = true
+	}
+
+	return delivered
+}
+
+// responderFor yields the nearest mesh, starting with this one, which has a
+// handler registered for the topic.
+func (m *mesh) responderFor(topic string) *mesh {
+	for candidate := m; candidate != nil; candidate = candidate.parent {
+		candidate.mu.RLock()
+		found := candidate.responders[topic]
+		candidate.mu.RUnlock()
+
+		if found {
+			return candidate
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package servicemesh
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequest(t *testing.T) {
+	m := New()
+
+	_, err := m.Handle("add", func(_ context.Context, payload any) (any, error) {
+		numbers, ok := payload.([]int)
+		if !ok {
+			return nil, errors.New("expected numbers")
+		}
+
+		return numbers[0] + numbers[1], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = m.Handle("add", nil); !errors.Is(err, ErrResponderExists) {
+		t.Errorf("expected a second handler to be rejected, got %v", err)
+	}
+
+	reply, err := m.Request(context.Background(), "add", []int{2, 3})
+	if err != nil || reply != 5 {
+		t.Errorf("unexpected reply: %v, %v", reply, err)
+	}
+
+	if _, err = m.Request(context.Background(), "add", "nope"); err == nil {
+		t.Error("expected the handler error to be propagated")
+	}
+
+	if _, err = m.Request(context.Background(), "subtract", nil); !errors.Is(err, ErrNoResponder) {
+		t.Errorf("expected ErrNoResponder, got %v", err)
+	}
+
+	// requests from a child mesh are answered by the parent
+	if reply, err = m.NewChild("child").Request(context.Background(), "add", []int{1, 1}); reply != 2 {
+		t.Errorf("unexpected reply from child mesh: %v, %v", reply, err)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	m := New()
+
+	unsubscribe, _ := m.Handle("slow", func(ctx context.Context, _ any) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	if _, err := m.Request(ctx, "slow", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline to be exceeded, got %v", err)
+	}
+
+	unsubscribe()
+
+	if _, err := m.Request(context.Background(), "slow", nil); !errors.Is(err, ErrNoResponder) {
+		t.Errorf("expected handler to be removed, got %v", err)
+	}
+}
+
+func TestRequestIsolatedFromEvents(t *testing.T) {
+	m := New()
+	m.SetEventHistoryRetention(100)
+
+	var mu sync.Mutex
+	var seen []string
+
+	SubscribeAll(m, func(e Event) {
+		mu.Lock()
+		seen = append(seen, e.Name)
+		mu.Unlock()
+	}, WithDelivery(DeliverySync, 0))
+
+	_, _ = m.Handle("echo", func(_ context.Context, payload any) (any, error) {
+		return payload, nil
+	})
+
+	if reply, err := m.Request(context.Background(), "echo", 1); err != nil || reply != 1 {
+		t.Fatalf("unexpected reply: %v, %v", reply, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, name := range seen {
+		if name == requestTopic("echo") {
+			t.Error("expected requests not to reach pattern subscribers")
+		}
+	}
+
+	for _, e := range m.EventHistory() {
+		if e.Name == requestTopic("echo") {
+			t.Error("expected requests not to be recorded in the event history")
+		}
+	}
+
+	// a request dropped by the middleware fails right away
+	m.UseEventMiddleware(func(next EventHandlerFunc) EventHandlerFunc {
+		return func(e Event) {
+			if e.Name != requestTopic("echo") {
+				next(e)
+			}
+		}
+	})
+
+	start := time.Now()
+
+	if _, err := m.Request(context.Background(), "echo", 1); !errors.Is(err, ErrRequestNotDelivered) {
+		t.Errorf("expected ErrRequestNotDelivered, got %v", err)
+	}
+
+	if time.Since(start) > time.Second {
+		t.Error("expected the dropped request to fail without waiting for a timeout")
+	}
+}
@@ -0,0 +1,408 @@
+package servicemesh
+
+import (
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// The following types are the typed counterparts of the events found in
+// events.go. They can be used with Subscribe and Publish instead of the
+// string event names and untyped arguments.
+
+// ServiceAdded is the typed form of EventServiceAdded.
+type ServiceAdded struct {
+	Service Service
+	Time    time.Time
+}
+
+// ServiceRemoved is the typed form of EventServiceRemoved.
+type ServiceRemoved struct {
+	Service Service
+	Time    time.Time
+}
+
+// ServiceInitialized is the typed form of EventServiceInitialized.
+type ServiceInitialized struct {
+	Service Service
+	Time    time.Time
+}
+
+// ServiceEventsBound is the typed form of EventServiceEventsBound.
+type ServiceEventsBound struct {
+	Service Service
+	Time    time.Time
+}
+
+// ServiceLoggerBound is the typed form of EventServiceLoggerBound.
+type ServiceLoggerBound struct {
+	Service Service
+	Time    time.Time
+}
+
+// ServiceInitDeferred is the typed form of EventServiceInitDeferred.
+type ServiceInitDeferred struct {
+	Service Service
+	Time    time.Time
+}
+
+// ServiceDeferredInitStarted is the typed form of
+// EventServiceDeferredInitStarted.
+type ServiceDeferredInitStarted struct {
+	Service Service
+	Time    time.Time
+}
+
+// GroupStarted is the typed form of EventGroupStarted.
+type GroupStarted struct {
+	Group string
+	Time  time.Time
+}
+
+// GroupStopped is the typed form of EventGroupStopped.
+type GroupStopped struct {
+	Group string
+	Time  time.Time
+}
+
+// GroupRemoved is the typed form of EventGroupRemoved.
+type GroupRemoved struct {
+	Group string
+	Time  time.Time
+}
+
+// ReplicasScaled is the typed form of EventReplicasScaled.
+type ReplicasScaled struct {
+	Name     string
+	Replicas int
+	Time     time.Time
+}
+
+// RemoteServiceAdded is the typed form of EventRemoteServiceAdded.
+type RemoteServiceAdded struct {
+	Service Service
+	Time    time.Time
+}
+
+// RemoteServiceRemoved is the typed form of EventRemoteServiceRemoved.
+type RemoteServiceRemoved struct {
+	Service Service
+	Time    time.Time
+}
+
+// LogLevelChange is the typed form of EventLogLevelChange. The Target is
+// empty for the log level of the mesh, the name of a service, or the name of
+// a group prefixed with "group:".
+type LogLevelChange struct {
+	Target string
+	Level  slog.Level
+	Time   time.Time
+}
+
+// HandlerFailed is the typed form of EventHandlerFailed.
+type HandlerFailed struct {
+	Event    string
+	Handler  string
+	Err      error
+	Attempts int
+	Time     time.Time
+}
+
+// ServiceMeshRunLoopInitiated is the typed form of
+// EventServiceMeshRunLoopInitiated.
+type ServiceMeshRunLoopInitiated struct {
+	Time time.Time
+}
+
+// ServiceMeshShutdownInitiated is the typed form of
+// EventServiceMeshShutdownInitiated.
+type ServiceMeshShutdownInitiated struct {
+	Time time.Time
+}
+
+// DependencyResolutionStarted is the typed form of
+// EventDependencyResolutionStarted.
+type DependencyResolutionStarted struct {
+	Service Service
+	Time    time.Time
+}
+
+// DependencyResolutionEnded is the typed form of
+// EventDependencyResolutionEnded.
+type DependencyResolutionEnded struct {
+	Service Service
+	Time    time.Time
+}
+
+// eventCodec converts between a typed event and the topic and arguments it
+// is emitted with on the event bus.
+type eventCodec struct {
+	topic  string
+	encode func(evt any) []any
+	decode func(e Event) (any, bool)
+}
+
+// builtinEvents maps the typed events of the mesh onto the string events
+// found in events.go, so that both forms describe the same event.
+var builtinEvents = map[reflect.Type]eventCodec{}
+
+// typedTopics maps the topics of the user-defined typed events which were
+// published or subscribed to onto their types, so that events received in a
+// generic form (such as over an EventBridge) can be restored to their type.
+var typedTopics sync.Map
+
+func init() {
+	serviceEvent(EventServiceAdded, func(s Service, t time.Time) ServiceAdded { return ServiceAdded{s, t} })
+	serviceEvent(EventServiceRemoved, func(s Service, t time.Time) ServiceRemoved { return ServiceRemoved{s, t} })
+	serviceEvent(EventServiceInitialized, func(s Service, t time.Time) ServiceInitialized { return ServiceInitialized{s, t} })
+	serviceEvent(EventServiceEventsBound, func(s Service, t time.Time) ServiceEventsBound { return ServiceEventsBound{s, t} })
+	serviceEvent(EventServiceLoggerBound, func(s Service, t time.Time) ServiceLoggerBound { return ServiceLoggerBound{s, t} })
+	serviceEvent(EventServiceInitDeferred, func(s Service, t time.Time) ServiceInitDeferred { return ServiceInitDeferred{s, t} })
+	serviceEvent(EventServiceDeferredInitStarted, func(s Service, t time.Time) ServiceDeferredInitStarted { return ServiceDeferredInitStarted{s, t} })
+	serviceEvent(EventDependencyResolutionStarted, func(s Service, t time.Time) DependencyResolutionStarted { return DependencyResolutionStarted{s, t} })
+	serviceEvent(EventDependencyResolutionEnded, func(s Service, t time.Time) DependencyResolutionEnded { return DependencyResolutionEnded{s, t} })
+
+	groupEvent(EventGroupStarted, func(g string, t time.Time) GroupStarted { return GroupStarted{g, t} })
+	groupEvent(EventGroupStopped, func(g string, t time.Time) GroupStopped { return GroupStopped{g, t} })
+	groupEvent(EventGroupRemoved, func(g string, t time.Time) GroupRemoved { return GroupRemoved{g, t} })
+
+	builtinEvent(EventReplicasScaled,
+		func(e ReplicasScaled) []any { return []any{e.Name, e.Replicas} },
+		func(e Event) (ReplicasScaled, bool) {
+			if len(e.Args) < 2 {
+				return ReplicasScaled{}, false
+			}
+
+			name, nameOk := e.Args[0].(string)
+			replicas, replicasOk := e.Args[1].(int)
+
+			return ReplicasScaled{name, replicas, e.Envelope.Time}, nameOk && replicasOk
+		})
+
+	serviceEvent(EventRemoteServiceAdded, func(s Service, t time.Time) RemoteServiceAdded { return RemoteServiceAdded{s, t} })
+	serviceEvent(EventRemoteServiceRemoved, func(s Service, t time.Time) RemoteServiceRemoved { return RemoteServiceRemoved{s, t} })
+
+	builtinEvent(EventLogLevelChange,
+		func(e LogLevelChange) []any { return []any{e.Target, e.Level} },
+		func(e Event) (LogLevelChange, bool) {
+			if len(e.Args) < 2 {
+				return LogLevelChange{}, false
+			}
+
+			target, targetOk := e.Args[0].(string)
+			level, levelOk := e.Args[1].(slog.Level)
+
+			return LogLevelChange{target, level, e.Envelope.Time}, targetOk && levelOk
+		})
+
+	builtinEvent(EventHandlerFailed,
+		func(e HandlerFailed) []any { return []any{e.Event, e.Handler, e.Err, e.Attempts} },
+		func(e Event) (HandlerFailed, bool) {
+			if len(e.Args) < 4 {
+				return HandlerFailed{}, false
+			}
+
+			event, eventOk := e.Args[0].(string)
+			handler, handlerOk := e.Args[1].(string)
+			err, _ := e.Args[2].(error)
+			attempts, attemptsOk := e.Args[3].(int)
+
+			return HandlerFailed{event, handler, err, attempts, e.Envelope.Time}, eventOk && handlerOk && attemptsOk
+		})
+
+	builtinEvent(EventServiceMeshRunLoopInitiated,
+		func(ServiceMeshRunLoopInitiated) []any { return nil },
+		func(e Event) (ServiceMeshRunLoopInitiated, bool) {
+			return ServiceMeshRunLoopInitiated{e.Envelope.Time}, true
+		})
+
+	builtinEvent(EventServiceMeshShutdownInitiated,
+		func(ServiceMeshShutdownInitiated) []any { return nil },
+		func(e Event) (ServiceMeshShutdownInitiated, bool) {
+			return ServiceMeshShutdownInitiated{e.Envelope.Time}, true
+		})
+}
+
+func builtinEvent[E any](topic string, encode func(E) []any, decode func(Event) (E, bool)) {
+	builtinEvents[reflect.TypeOf((*E)(nil)).Elem()] = eventCodec{
+		topic: topic,
+		encode: func(evt any) []any {
+			return encode(evt.(E))
+		},
+		decode: func(e Event) (any, bool) {
+			return decode(e)
+		},
+	}
+}
+
+// serviceEvent registers a built-in event whose only argument is a service.
+func serviceEvent[E any](topic string, build func(Service, time.Time) E) {
+	builtinEvent(topic,
+		func(evt E) []any {
+			return []any{reflect.ValueOf(evt).Field(0).Interface()}
+		},
+		func(e Event) (E, bool) {
+			var zero E
+
+			if len(e.Args) < 1 {
+				return zero, false
+			}
+
+			service, ok := e.Args[0].(Service)
+			if !ok {
+				return zero, false
+			}
+
+			return build(service, e.Envelope.Time), true
+		})
+}
+
+// groupEvent registers a built-in event whose only argument is a group name.
+func groupEvent[E any](topic string, build func(string, time.Time) E) {
+	builtinEvent(topic,
+		func(evt E) []any {
+			return []any{reflect.ValueOf(evt).Field(0).Interface()}
+		},
+		func(e Event) (E, bool) {
+			var zero E
+
+			if len(e.Args) < 1 {
+				return zero, false
+			}
+
+			group, ok := e.Args[0].(string)
+			if !ok {
+				return zero, false
+			}
+
+			return build(group, e.Envelope.Time), true
+		})
+}
+
+// codecFor yields the codec for events of type E. Built-in events use the
+// topics found in events.go, and any other type is published on a topic
+// named after the type.
+func codecFor[E any]() eventCodec {
+	typ := reflect.TypeOf((*E)(nil)).Elem()
+
+	if codec, found := builtinEvents[typ]; found {
+		return codec
+	}
+
+	typedTopics.Store(typeTopic(typ), typ)
+
+	return eventCodec{
+		topic: typeTopic(typ),
+		encode: func(evt any) []any {
+			return []any{evt}
+		},
+		decode: func(e Event) (any, bool) {
+			if len(e.Args) < 1 {
+				return nil, false
+			}
+
+			evt, ok := e.Args[0].(E)
+
+			return evt, ok
+		},
+	}
+}
+
+// typeTopic yields the name of the topic which events of the given type are
+// published on.
+func typeTopic(typ reflect.Type) string {
+	if typ.Name() != "" && typ.PkgPath() != "" {
+		return "type " + typ.PkgPath() + "." + typ.Name()
+	}
+
+	return "type " + typ.String()
+}
+
+// Publish emits a typed event on the event bus of the mesh. Every subscriber
+// of the type E receives the event. Optionally, an Envelope can be given to
+// set the Source, SourceID, and CorrelationID of the event. The returned
+// Operation completes once every subscriber has handled the event.
+func Publish[E any](m Mesh, evt E, meta ...Envelope) *Operation {
+	codec := codecFor[E]()
+	args := codec.encode(evt)
+
+	if impl, ok := m.(*mesh); ok {
+		env := Envelope{Source: impl.name, SourceID: impl.ServiceID(impl)}
+		if len(meta) > 0 {
+			env = meta[0]
+		}
+
+		return operationFromWaitGroup(impl.dispatch(codec.topic, args, env))
+	}
+
+	return operationFromWaitGroup(m.Events().Emit(codec.topic, args...))
+}
+
+// Subscribe binds a handler for events of type E to the event bus of the
+// mesh. Each subscriber receives events in the order they were published.
+// The returned function removes the subscription.
+func Subscribe[E any](m Mesh, fn func(E), opts ...SubscribeOption) Unsubscribe {
+	return SubscribeEnvelope(m, func(evt E, _ Envelope) {
+		fn(evt)
+	}, opts...)
+}
+
+// SubscribeEnvelope is like Subscribe, but the handler also receives the
+// envelope of each event.
+func SubscribeEnvelope[E any](m Mesh, fn func(E, Envelope), opts ...SubscribeOption) Unsubscribe {
+	return subscribeTyped(m, func(evt E, env Envelope) error {
+		fn(evt, env)
+		return nil
+	}, opts...)
+}
+
+// SubscribeErr is like Subscribe, for a handler which may fail. An event the
+// handler fails to handle, after any retries set with WithRetry, is passed to
+// the dead-letter sink of the mesh and reported with EventHandlerFailed.
+func SubscribeErr[E any](m Mesh, fn func(E) error, opts ...SubscribeOption) Unsubscribe {
+	return subscribeTyped(m, func(evt E, _ Envelope) error {
+		return fn(evt)
+	}, opts...)
+}
+
+func subscribeTyped[E any](m Mesh, fn func(E, Envelope) error, opts ...SubscribeOption) Unsubscribe {
+	codec := codecFor[E]()
+
+	handle := func(e Event) error {
+		if evt, ok := codec.decode(e); ok {
+			return fn(evt.(E), e.Envelope)
+		}
+
+		return nil
+	}
+
+	if impl, ok := meshOf(m); ok {
+		return impl.subscribeErr(codec.topic, handle, opts...)
+	}
+
+	return subscribeRaw(m, codec.topic, handle)
+}
+
+// subscribeRaw binds a handler to the raw event emitter of a Mesh which has
+// no event bus of its own.
+func subscribeRaw(m Mesh, topic string, handle func(Event) error) Unsubscribe {
+	listener := func(args ...any) {
+		env := Envelope{Time: time.Now()}
+
+		if n := len(args); n > 0 {
+			if meta, ok := args[n-1].(Envelope); ok {
+				env = meta
+				args = args[:n-1]
+			}
+		}
+
+		_ = handle(Event{Name: topic, Args: args, Envelope: env})
+	}
+
+	m.Events().On(topic, listener)
+
+	return func() {
+		m.Events().Off(topic, listener)
+	}
+}
@@ -0,0 +1,213 @@
+package servicemesh
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type orderPlaced struct {
+	ID int
+}
+
+func TestTypedEvents(t *testing.T) {
+	m := New()
+
+	received := make(chan int, 10)
+	unsubscribe := Subscribe(m, func(e orderPlaced) {
+		received <- e.ID
+	})
+
+	for id := 1; id <= 3; id++ {
+		_ = Publish(m, orderPlaced{ID: id}).Wait(context.Background())
+	}
+
+	for want := 1; want <= 3; want++ {
+		if got := <-received; got != want {
+			t.Errorf("expected event %d, got %d", want, got)
+		}
+	}
+
+	unsubscribe()
+	_ = Publish(m, orderPlaced{ID: 4}).Wait(context.Background())
+
+	select {
+	case id := <-received:
+		t.Errorf("received event %d after unsubscribing", id)
+	default:
+	}
+}
+
+func TestTypedBuiltinEvents(t *testing.T) {
+	m := New()
+	s := &providerService{}
+
+	added := make(chan ServiceAdded, 1)
+	Subscribe(m, func(e ServiceAdded) {
+		if e.Service == s {
+			added <- e
+		}
+	})
+
+	m.Add(s)
+
+	select {
+	case e := <-added:
+		if e.Time.IsZero() {
+			t.Error("expected event to have a time")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected typed ServiceAdded event")
+	}
+}
+
+func TestEnvelopes(t *testing.T) {
+	m := New("audited")
+
+	envelopes := make(chan Envelope, 10)
+	SubscribeEnvelope(m, func(_ orderPlaced, env Envelope) {
+		envelopes <- env
+	})
+
+	_ = Publish(m, orderPlaced{ID: 1}).Wait(context.Background())
+	_ = Publish(m, orderPlaced{ID: 2}, Envelope{Source: "checkout", CorrelationID: "abc"}).Wait(context.Background())
+
+	first, second := <-envelopes, <-envelopes
+
+	if first.Sequence >= second.Sequence {
+		t.Errorf("expected increasing sequence numbers, got %d and %d", first.Sequence, second.Sequence)
+	}
+
+	if first.Mesh != "audited" || first.Source != "audited" || first.SourceID == "" {
+		t.Errorf("unexpected envelope for event emitted by the mesh: %+v", first)
+	}
+
+	if second.Source != "checkout" || second.CorrelationID != "abc" {
+		t.Errorf("expected caller supplied metadata, got %+v", second)
+	}
+
+	raw := make(chan []any, 1)
+	m.Events().On("custom", func(args ...any) { raw <- args })
+	_ = m.Emit("custom", 42).Wait(context.Background())
+
+	args := <-raw
+	if len(args) != 2 || args[0] != 42 {
+		t.Fatalf("unexpected raw arguments: %v", args)
+	}
+
+	if _, ok := args[1].(Envelope); !ok {
+		t.Error("expected raw listeners to receive the envelope as the last argument")
+	}
+}
+
+func TestEventHistorySequence(t *testing.T) {
+	m := New()
+	m.SetEventHistoryRetention(1000)
+
+	// hold back some of the events before they are published
+	m.UseEventMiddleware(func(next EventHandlerFunc) EventHandlerFunc {
+		return func(e Event) {
+			if e.Name == "tick" && e.Handler == "" && e.Args[0].(int)%10 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			next(e)
+		}
+	})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				m.Emit("tick", j)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	var last uint64
+
+	for _, e := range m.EventHistory() {
+		if e.Envelope.Sequence <= last {
+			t.Fatalf("expected the history to be ordered by sequence, got %d after %d", e.Envelope.Sequence, last)
+		}
+
+		last = e.Envelope.Sequence
+	}
+}
+
+func TestEventHistoryReplay(t *testing.T) {
+	m := New()
+	m.SetEventHistoryRetention(2)
+
+	for id := 1; id <= 3; id++ {
+		_ = Publish(m, orderPlaced{ID: id}).Wait(context.Background())
+	}
+
+	if n := len(m.EventHistory()); n != 2 {
+		t.Fatalf("expected history to be bounded to 2 events, got %d", n)
+	}
+
+	received := make(chan int, 10)
+	Subscribe(m, func(e orderPlaced) {
+		received <- e.ID
+	}, WithReplay())
+
+	_ = Publish(m, orderPlaced{ID: 4}).Wait(context.Background())
+
+	for _, want := range []int{2, 3, 4} {
+		if got := <-received; got != want {
+			t.Errorf("expected event %d, got %d", want, got)
+		}
+	}
+}
+
+func TestEventHistoryReplaySync(t *testing.T) {
+	m := New()
+	m.SetEventHistoryRetention(2)
+
+	for id := 1; id <= 3; id++ {
+		_ = Publish(m, orderPlaced{ID: id}).Wait(context.Background())
+	}
+
+	var mu sync.Mutex
+	var received []int
+
+	replaying := make(chan struct{})
+	live := make(chan struct{})
+
+	go func() {
+		<-replaying
+		_ = Publish(m, orderPlaced{ID: 4}).Wait(context.Background())
+		close(live)
+	}()
+
+	Subscribe(m, func(e orderPlaced) {
+		if e.ID == 2 {
+			close(replaying)
+
+			// give the live event a chance to overtake the history
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		mu.Lock()
+		received = append(received, e.ID)
+		mu.Unlock()
+	}, WithReplay(), WithDelivery(DeliverySync, 0))
+
+	<-live
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 3 || received[0] != 2 || received[1] != 3 || received[2] != 4 {
+		t.Errorf("expected the history to be replayed before live events, got %v", received)
+	}
+}